@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"smartTables/config"
 	"smartTables/internal/handler"
 	"smartTables/internal/service"
@@ -11,10 +12,15 @@ func main() {
 	conf := config.New()
 	stM, err := storage.NewPostgresDBStorage(conf)
 	if err != nil {
-		return
+		log.Fatalf("can't connect to storage: %v", err)
 	}
 	sr := service.NewService(stM, conf)
-	h := handler.NewHandler(sr, conf)
+	defer sr.Close()
+	h, err := handler.NewHandler(sr, conf)
+	if err != nil {
+		sr.Close()
+		log.Fatalf("can't create handler: %v", err)
+	}
 	h.Start()
 
 }
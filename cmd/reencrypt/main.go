@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+	"smartTables/config"
+	"smartTables/internal/service"
+	"smartTables/internal/storage"
+)
+
+// Перешифровывает строки подключения в таблице connections активным ключом из конфига.
+// Запускать после добавления нового ключа и смены encryptionKeyId; старый ключ можно
+// убрать из конфига только после успешного завершения.
+func main() {
+	conf := config.New()
+	stM, err := storage.NewPostgresDBStorage(conf)
+	if err != nil {
+		log.Fatalf("Не удалось подключиться к базе: %v", err)
+	}
+	defer stM.Close()
+
+	sr := service.NewService(stM, conf)
+	defer sr.Close()
+
+	n, err := sr.Reencrypt(context.Background())
+	if err != nil {
+		log.Fatalf("Перешифровано %d записей, ошибка: %v", n, err)
+	}
+	log.Printf("Перешифровано записей: %d", n)
+}
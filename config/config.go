@@ -14,6 +14,36 @@ type Config struct {
 	DB       string `json:"dsn"`
 	Salt     string `json:""`
 	CFile    string
+	// Пулы к пользовательским базам, время в секундах
+	PoolMaxOpenConns    int `json:"poolMaxOpenConns"`
+	PoolMaxIdleConns    int `json:"poolMaxIdleConns"`
+	PoolConnMaxIdleTime int `json:"poolConnMaxIdleTime"`
+	PoolIdleTimeout     int `json:"poolIdleTimeout"`
+	// Мастер-ключи (base64, 32 байта) для шифрования строк подключения и ID активного ключа
+	EncryptionKeys  map[string]string `json:"encryptionKeys"`
+	EncryptionKeyID string            `json:"encryptionKeyId"`
+	// Таймаут запроса по умолчанию и максимальный таймаут, который можно запросить, в секундах
+	QueryTimeout    int `json:"queryTimeout"`
+	QueryMaxTimeout int `json:"queryMaxTimeout"`
+	// Максимум строк, которые сервер читает из одного результата
+	QueryMaxRows int `json:"queryMaxRows"`
+	// Прокси, которым можно верить в X-Forwarded-For; без них в историю пишется адрес соединения
+	TrustedProxies []string `json:"trustedProxies"`
+	// Файл, в который журнал аудита дублируется строками JSON; пусто - только в базу
+	AuditFile string `json:"auditFile"`
+	// Логины, которые всегда администраторы, независимо от роли в users: так назначается первый администратор.
+	// Новые пользователи регистрируются с ролью viewer.
+	Admins []string `json:"admins"`
+	// Ключи подписи cookie сессий (base64, от 32 байт). Первый подписывает новые cookie, остальные
+	// принимаются для уже выданных - так ключ меняют без выхода пользователей. Без ключей он
+	// генерируется при старте и все сессии теряются при перезапуске.
+	SessionKeys []string `json:"sessionKeys"`
+	// Где хранить сессии: "cookie" (по умолчанию) или "postgres" - тогда их можно просматривать и отзывать
+	SessionStore string `json:"sessionStore"`
+	// Параметры cookie сессии: cookieSecure - только по HTTPS, cookieSameSite - "lax" (по умолчанию),
+	// "strict" или "none" (требует cookieSecure)
+	CookieSecure   bool   `json:"cookieSecure"`
+	CookieSameSite string `json:"cookieSameSite"`
 }
 
 type F struct {
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"smartTables/internal/clientip"
+	"smartTables/internal/shema"
+	"time"
+)
+
+const writeTimeout = 5 * time.Second
+
+// Sink - получатель событий журнала
+type Sink interface {
+	Write(ctx context.Context, e shema.AuditEvent) error
+}
+
+// Store - хранилище журнала в основной базе
+type Store interface {
+	SaveAuditEvent(ctx context.Context, e shema.AuditEvent) error
+}
+
+type storeSink struct {
+	store Store
+}
+
+// StoreSink пишет события в таблицу audit_log
+func StoreSink(store Store) Sink {
+	return storeSink{store: store}
+}
+
+func (s storeSink) Write(ctx context.Context, e shema.AuditEvent) error {
+	return s.store.SaveAuditEvent(ctx, e)
+}
+
+// Log раздает события всем получателям. Журнал только дополняется: изменять и удалять события нельзя.
+type Log struct {
+	sinks  []Sink
+	logger *zap.Logger
+}
+
+func New(logger *zap.Logger, sinks ...Sink) *Log {
+	return &Log{sinks: sinks, logger: logger}
+}
+
+// Record пишет событие. Время и адрес клиента из контекста проставляются, если не заданы.
+// Ошибки получателей только логируются, чтобы сбой журнала не ломал действие пользователя.
+func (l *Log) Record(ctx context.Context, e shema.AuditEvent) {
+	const op = "audit.Record"
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.ClientIP == "" {
+		e.ClientIP = clientip.From(ctx)
+	}
+
+	// событие нужно и тогда, когда запрос пользователя уже отменен
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	for _, sink := range l.sinks {
+		if err := sink.Write(writeCtx, e); err != nil {
+			l.logger.Error(fmt.Sprintf("%s : %s %s: %v", op, e.Action, e.Actor, err))
+		}
+	}
+}
+
+// Close закрывает получателей, которым это нужно
+func (l *Log) Close() error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if c, ok := sink.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// QueryHash - sha256 текста запроса в hex; по нему событие можно сопоставить с историей
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"smartTables/internal/shema"
+	"sync"
+)
+
+// FileSink дописывает события в файл по одному JSON-объекту на строку - для отправки в систему сбора логов
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFile открывает файл журнала на дозапись, создавая его при необходимости
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+func (f *FileSink) Write(_ context.Context, e shema.AuditEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// одна запись на событие, чтобы строки разных событий не перемешивались
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(line)
+	return err
+}
+
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+)
+
+// Load читает схемы, таблицы, представления, колонки, ключи и индексы базы
+func Load(ctx context.Context, db *sql.DB, d dialect.Dialect) ([]shema.DBSchema, error) {
+	b := newBuilder()
+	var err error
+	switch d {
+	case dialect.Postgres:
+		err = loadPostgres(ctx, db, b)
+	case dialect.MySQL:
+		err = loadMySQL(ctx, db, b)
+	case dialect.SQLite:
+		err = loadSQLite(ctx, db, b)
+	default:
+		err = fmt.Errorf("unsupported database type: %s", d)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.result(), nil
+}
+
+// LoadTable читает одну таблицу или представление: колонки и первичный ключ, без внешних ключей и индексов.
+// Без схемы берется первая по порядку схема с такой таблицей. Если таблицы нет, возвращается nil.
+func LoadTable(ctx context.Context, db *sql.DB, d dialect.Dialect, schema, name string) (*shema.Table, error) {
+	switch d {
+	case dialect.Postgres:
+		return loadPostgresTable(ctx, db, schema, name)
+	case dialect.MySQL:
+		return loadMySQLTable(ctx, db, schema, name)
+	case dialect.SQLite:
+		return loadSQLiteTable(ctx, db, schema, name)
+	}
+	return nil, fmt.Errorf("unsupported database type: %s", d)
+}
+
+// builder собирает каталог из нескольких запросов, сохраняя порядок, в котором их вернула база
+type builder struct {
+	schemas []string
+	tables  map[string][]*shema.Table
+	byName  map[[2]string]*shema.Table
+}
+
+func newBuilder() *builder {
+	return &builder{tables: make(map[string][]*shema.Table), byName: make(map[[2]string]*shema.Table)}
+}
+
+func (b *builder) schema(name string) {
+	if _, ok := b.tables[name]; !ok {
+		b.schemas = append(b.schemas, name)
+		b.tables[name] = nil
+	}
+}
+
+func (b *builder) table(schema, name, kind string, estimate int64) {
+	b.schema(schema)
+	t := &shema.Table{Schema: schema, Name: name, Kind: kind, RowEstimate: estimate, Columns: []shema.TableColumn{}}
+	b.tables[schema] = append(b.tables[schema], t)
+	b.byName[[2]string{schema, name}] = t
+}
+
+// get возвращает таблицу или nil, если она не попала в список (например, системная)
+func (b *builder) get(schema, name string) *shema.Table {
+	return b.byName[[2]string{schema, name}]
+}
+
+func (b *builder) result() []shema.DBSchema {
+	res := make([]shema.DBSchema, 0, len(b.schemas))
+	for _, name := range b.schemas {
+		s := shema.DBSchema{Name: name, Tables: make([]shema.Table, 0, len(b.tables[name]))}
+		for _, t := range b.tables[name] {
+			s.Tables = append(s.Tables, *t)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"smartTables/internal/shema"
+)
+
+// Схемы MySQL - это базы; если в строке подключения база указана, показываем только ее
+const myUserSchemas = `table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+	AND (DATABASE() IS NULL OR table_schema = DATABASE())`
+
+const myTables = `SELECT table_schema, table_name, table_type, table_rows
+FROM information_schema.tables WHERE ` + myUserSchemas + `
+ORDER BY table_schema, table_name`
+
+const myColumns = `SELECT table_schema, table_name, column_name, column_type, is_nullable, column_default
+FROM information_schema.columns WHERE ` + myUserSchemas + `
+ORDER BY table_schema, table_name, ordinal_position`
+
+const myKeys = `SELECT table_schema, table_name, constraint_name, column_name,
+	referenced_table_schema, referenced_table_name, referenced_column_name
+FROM information_schema.key_column_usage WHERE ` + myUserSchemas + `
+ORDER BY table_schema, table_name, constraint_name, ordinal_position`
+
+const myIndexes = `SELECT table_schema, table_name, index_name, non_unique, COALESCE(column_name, '')
+FROM information_schema.statistics WHERE ` + myUserSchemas + `
+ORDER BY table_schema, table_name, index_name, seq_in_index`
+
+const myTable = `SELECT table_schema, table_name, table_type
+FROM information_schema.tables WHERE ` + myUserSchemas + ` AND table_name = ? AND (? = '' OR table_schema = ?)
+ORDER BY table_schema
+LIMIT 1`
+
+const myTableColumns = `SELECT column_name, column_type, is_nullable, column_default
+FROM information_schema.columns WHERE table_schema = ? AND table_name = ?
+ORDER BY ordinal_position`
+
+const myPrimaryKey = `SELECT column_name
+FROM information_schema.key_column_usage WHERE table_schema = ? AND table_name = ? AND constraint_name = 'PRIMARY'
+ORDER BY ordinal_position`
+
+func loadMySQL(ctx context.Context, db *sql.DB, b *builder) error {
+	if err := eachRow(ctx, db, myTables, func(rows *sql.Rows) error {
+		var schema, name, kind string
+		var estimate sql.NullInt64
+		if err := rows.Scan(&schema, &name, &kind, &estimate); err != nil {
+			return err
+		}
+		if kind == "VIEW" || kind == "SYSTEM VIEW" {
+			b.table(schema, name, shema.TableKindView, -1)
+			return nil
+		}
+		if !estimate.Valid {
+			estimate.Int64 = -1
+		}
+		b.table(schema, name, shema.TableKindTable, estimate.Int64)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := eachRow(ctx, db, myColumns, func(rows *sql.Rows) error {
+		var schema, table, nullable string
+		var col shema.TableColumn
+		var def sql.NullString
+		if err := rows.Scan(&schema, &table, &col.Name, &col.Type, &nullable, &def); err != nil {
+			return err
+		}
+		col.Nullable = nullable == "YES"
+		col.Default = nullString(def)
+		if t := b.get(schema, table); t != nil {
+			t.Columns = append(t.Columns, col)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := eachRow(ctx, db, myKeys, func(rows *sql.Rows) error {
+		var schema, table, name, column string
+		var refSchema, refTable, refColumn sql.NullString
+		if err := rows.Scan(&schema, &table, &name, &column, &refSchema, &refTable, &refColumn); err != nil {
+			return err
+		}
+		t := b.get(schema, table)
+		switch {
+		case t == nil:
+		case name == "PRIMARY":
+			t.PrimaryKey = append(t.PrimaryKey, column)
+		case refTable.Valid:
+			// строки одного внешнего ключа идут подряд
+			if n := len(t.ForeignKeys); n == 0 || t.ForeignKeys[n-1].Name != name {
+				t.ForeignKeys = append(t.ForeignKeys, shema.ForeignKey{Name: name, RefSchema: refSchema.String, RefTable: refTable.String})
+			}
+			fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn.String)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return eachRow(ctx, db, myIndexes, func(rows *sql.Rows) error {
+		var schema, table, name, column string
+		var nonUnique int
+		if err := rows.Scan(&schema, &table, &name, &nonUnique, &column); err != nil {
+			return err
+		}
+		t := b.get(schema, table)
+		if t == nil {
+			return nil
+		}
+		if n := len(t.Indexes); n == 0 || t.Indexes[n-1].Name != name {
+			t.Indexes = append(t.Indexes, shema.Index{Name: name, Unique: nonUnique == 0, Primary: name == "PRIMARY"})
+		}
+		if column == "" {
+			// функциональная часть индекса
+			column = "(expression)"
+		}
+		idx := &t.Indexes[len(t.Indexes)-1]
+		idx.Columns = append(idx.Columns, column)
+		return nil
+	})
+}
+
+func loadMySQLTable(ctx context.Context, db *sql.DB, schema, name string) (*shema.Table, error) {
+	var kind string
+	t := &shema.Table{Kind: shema.TableKindTable, RowEstimate: -1, Columns: []shema.TableColumn{}}
+	err := db.QueryRowContext(ctx, myTable, name, schema, schema).Scan(&t.Schema, &t.Name, &kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kind == "VIEW" || kind == "SYSTEM VIEW" {
+		t.Kind = shema.TableKindView
+	}
+
+	if err := eachRow(ctx, db, myTableColumns, func(rows *sql.Rows) error {
+		var nullable string
+		var col shema.TableColumn
+		var def sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &nullable, &def); err != nil {
+			return err
+		}
+		col.Nullable = nullable == "YES"
+		col.Default = nullString(def)
+		t.Columns = append(t.Columns, col)
+		return nil
+	}, t.Schema, t.Name); err != nil {
+		return nil, err
+	}
+
+	if err := eachRow(ctx, db, myPrimaryKey, func(rows *sql.Rows) error {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return err
+		}
+		t.PrimaryKey = append(t.PrimaryKey, column)
+		return nil
+	}, t.Schema, t.Name); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
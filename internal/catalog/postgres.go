@@ -0,0 +1,217 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"smartTables/internal/shema"
+)
+
+const pgUserSchemas = `n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp\_%'`
+
+const pgSchemas = `SELECT n.nspname FROM pg_namespace n WHERE ` + pgUserSchemas + ` ORDER BY n.nspname`
+
+const pgRelations = `SELECT n.nspname, c.relname, c.relkind, c.reltuples::bigint
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p', 'f', 'v', 'm') AND ` + pgUserSchemas + `
+ORDER BY n.nspname, c.relname`
+
+const pgColumns = `SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, pg_get_expr(d.adbin, d.adrelid)
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attnum > 0 AND NOT a.attisdropped AND c.relkind IN ('r', 'p', 'f', 'v', 'm') AND ` + pgUserSchemas + `
+ORDER BY n.nspname, c.relname, a.attnum`
+
+const pgConstraints = `SELECT n.nspname, c.relname, con.conname, con.contype,
+	ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[],
+	rn.nspname, rc.relname,
+	ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[]
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_class rc ON rc.oid = con.confrelid
+LEFT JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+WHERE con.contype IN ('p', 'f') AND ` + pgUserSchemas + `
+ORDER BY n.nspname, c.relname, con.conname`
+
+const pgIndexes = `SELECT n.nspname, t.relname, i.relname, ix.indisunique, ix.indisprimary,
+	ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k, true) FROM generate_series(1, ix.indnatts) k)::text[]
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE ` + pgUserSchemas + `
+ORDER BY n.nspname, t.relname, i.relname`
+
+const pgTable = `SELECT c.oid, n.nspname, c.relname, c.relkind
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p', 'f', 'v', 'm') AND ` + pgUserSchemas + ` AND c.relname = $1 AND ($2 = '' OR n.nspname = $2)
+ORDER BY n.nspname
+LIMIT 1`
+
+const pgTableColumns = `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, pg_get_expr(d.adbin, d.adrelid)
+FROM pg_attribute a
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`
+
+const pgPrimaryKey = `SELECT a.attname
+FROM pg_constraint con
+CROSS JOIN LATERAL unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+WHERE con.conrelid = $1 AND con.contype = 'p'
+ORDER BY k.ord`
+
+func loadPostgres(ctx context.Context, db *sql.DB, b *builder) error {
+	if err := eachRow(ctx, db, pgSchemas, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		b.schema(name)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := eachRow(ctx, db, pgRelations, func(rows *sql.Rows) error {
+		var schema, name, kind string
+		var estimate int64
+		if err := rows.Scan(&schema, &name, &kind, &estimate); err != nil {
+			return err
+		}
+		if kind == "v" {
+			estimate = -1
+		}
+		// reltuples = -1, пока таблицу ни разу не анализировали
+		b.table(schema, name, pgTableKind(kind), estimate)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := eachRow(ctx, db, pgColumns, func(rows *sql.Rows) error {
+		var schema, table string
+		var col shema.TableColumn
+		var def sql.NullString
+		if err := rows.Scan(&schema, &table, &col.Name, &col.Type, &col.Nullable, &def); err != nil {
+			return err
+		}
+		col.Default = nullString(def)
+		if t := b.get(schema, table); t != nil {
+			t.Columns = append(t.Columns, col)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := eachRow(ctx, db, pgConstraints, func(rows *sql.Rows) error {
+		var schema, table, name, kind string
+		var columns, refColumns []string
+		var refSchema, refTable sql.NullString
+		if err := rows.Scan(&schema, &table, &name, &kind, pq.Array(&columns), &refSchema, &refTable, pq.Array(&refColumns)); err != nil {
+			return err
+		}
+		t := b.get(schema, table)
+		if t == nil {
+			return nil
+		}
+		if kind == "p" {
+			t.PrimaryKey = columns
+			return nil
+		}
+		t.ForeignKeys = append(t.ForeignKeys, shema.ForeignKey{
+			Name:       name,
+			Columns:    columns,
+			RefSchema:  refSchema.String,
+			RefTable:   refTable.String,
+			RefColumns: refColumns,
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return eachRow(ctx, db, pgIndexes, func(rows *sql.Rows) error {
+		var schema, table string
+		var idx shema.Index
+		if err := rows.Scan(&schema, &table, &idx.Name, &idx.Unique, &idx.Primary, pq.Array(&idx.Columns)); err != nil {
+			return err
+		}
+		if t := b.get(schema, table); t != nil {
+			t.Indexes = append(t.Indexes, idx)
+		}
+		return nil
+	})
+}
+
+func loadPostgresTable(ctx context.Context, db *sql.DB, schema, name string) (*shema.Table, error) {
+	var oid int64
+	var kind string
+	t := &shema.Table{RowEstimate: -1, Columns: []shema.TableColumn{}}
+	err := db.QueryRowContext(ctx, pgTable, name, schema).Scan(&oid, &t.Schema, &t.Name, &kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Kind = pgTableKind(kind)
+
+	if err := eachRow(ctx, db, pgTableColumns, func(rows *sql.Rows) error {
+		var col shema.TableColumn
+		var def sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &def); err != nil {
+			return err
+		}
+		col.Default = nullString(def)
+		t.Columns = append(t.Columns, col)
+		return nil
+	}, oid); err != nil {
+		return nil, err
+	}
+
+	if err := eachRow(ctx, db, pgPrimaryKey, func(rows *sql.Rows) error {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return err
+		}
+		t.PrimaryKey = append(t.PrimaryKey, column)
+		return nil
+	}, oid); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// pgTableKind переводит pg_class.relkind в тип таблицы каталога
+func pgTableKind(relkind string) string {
+	switch relkind {
+	case "v":
+		return shema.TableKindView
+	case "m":
+		return shema.TableKindMaterializedView
+	}
+	return shema.TableKindTable
+}
+
+// eachRow выполняет запрос и вызывает fn для каждой строки
+func eachRow(ctx context.Context, db *sql.DB, query string, fn func(rows *sql.Rows) error, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
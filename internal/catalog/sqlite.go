@@ -0,0 +1,226 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+)
+
+const sqliteUserTables = `name NOT LIKE 'sqlite\_%' ESCAPE '\'`
+
+// В SQLite схемы - это main, temp и присоединенные базы.
+// Подробности по таблице читаются через табличные функции pragma_*, имена передаются параметрами.
+func loadSQLite(ctx context.Context, db *sql.DB, b *builder) error {
+	schemas, err := sqliteSchemas(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range schemas {
+		var tables []*shema.Table
+		if err := eachRow(ctx, db, "SELECT name, type FROM "+sqliteMaster(schema)+" WHERE type IN ('table', 'view') AND "+sqliteUserTables+" ORDER BY name", func(rows *sql.Rows) error {
+			var name, kind string
+			if err := rows.Scan(&name, &kind); err != nil {
+				return err
+			}
+			if kind == "view" {
+				b.table(schema, name, shema.TableKindView, -1)
+			} else {
+				b.table(schema, name, shema.TableKindTable, -1)
+			}
+			tables = append(tables, b.get(schema, name))
+			return nil
+		}); err != nil {
+			return err
+		}
+		if schema == "temp" && len(tables) == 0 {
+			continue
+		}
+		b.schema(schema)
+
+		estimates := sqliteEstimates(ctx, db, schema)
+		for _, t := range tables {
+			if n, ok := estimates[t.Name]; ok && t.Kind == shema.TableKindTable {
+				t.RowEstimate = n
+			}
+			if err := sqliteTable(ctx, db, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadSQLiteTable(ctx context.Context, db *sql.DB, schema, name string) (*shema.Table, error) {
+	schemas, err := sqliteSchemas(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schemas {
+		if schema != "" && s != schema {
+			continue
+		}
+		var kind string
+		err := db.QueryRowContext(ctx, "SELECT type FROM "+sqliteMaster(s)+" WHERE type IN ('table', 'view') AND name = ? AND "+sqliteUserTables, name).Scan(&kind)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		t := &shema.Table{Schema: s, Name: name, Kind: shema.TableKindTable, RowEstimate: -1, Columns: []shema.TableColumn{}}
+		if kind == "view" {
+			t.Kind = shema.TableKindView
+		}
+		if err := sqliteColumns(ctx, db, t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, nil
+}
+
+func sqliteSchemas(ctx context.Context, db *sql.DB) ([]string, error) {
+	var schemas []string
+	err := eachRow(ctx, db, "SELECT name FROM pragma_database_list ORDER BY seq", func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schemas = append(schemas, name)
+		return nil
+	})
+	return schemas, err
+}
+
+func sqliteMaster(schema string) string {
+	if schema == "temp" {
+		return "sqlite_temp_master"
+	}
+	return dialect.SQLite.Quote(schema) + ".sqlite_master"
+}
+
+func sqliteTable(ctx context.Context, db *sql.DB, t *shema.Table) error {
+	if err := sqliteColumns(ctx, db, t); err != nil {
+		return err
+	}
+	if t.Kind == shema.TableKindView {
+		return nil
+	}
+
+	if err := eachRow(ctx, db, `SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, func(rows *sql.Rows) error {
+		var id int
+		var ref, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &ref, &from, &to); err != nil {
+			return err
+		}
+		name := "fk_" + strconv.Itoa(id)
+		if n := len(t.ForeignKeys); n == 0 || t.ForeignKeys[n-1].Name != name {
+			t.ForeignKeys = append(t.ForeignKeys, shema.ForeignKey{Name: name, RefSchema: t.Schema, RefTable: ref})
+		}
+		fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, from)
+		// to пустой, если ключ ссылается на первичный ключ
+		fk.RefColumns = append(fk.RefColumns, to.String)
+		return nil
+	}, t.Name, t.Schema); err != nil {
+		return err
+	}
+
+	type indexInfo struct {
+		name    string
+		unique  bool
+		primary bool
+	}
+	var indexes []indexInfo
+	if err := eachRow(ctx, db, "SELECT name, \"unique\", origin FROM pragma_index_list(?, ?) ORDER BY name", func(rows *sql.Rows) error {
+		var i indexInfo
+		var origin string
+		if err := rows.Scan(&i.name, &i.unique, &origin); err != nil {
+			return err
+		}
+		i.primary = origin == "pk"
+		indexes = append(indexes, i)
+		return nil
+	}, t.Name, t.Schema); err != nil {
+		return err
+	}
+	for _, i := range indexes {
+		idx := shema.Index{Name: i.name, Unique: i.unique, Primary: i.primary}
+		if err := eachRow(ctx, db, "SELECT name FROM pragma_index_info(?, ?) ORDER BY seqno", func(rows *sql.Rows) error {
+			var name sql.NullString
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			if !name.Valid {
+				name.String = "(expression)"
+			}
+			idx.Columns = append(idx.Columns, name.String)
+			return nil
+		}, i.name, t.Schema); err != nil {
+			return err
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+	return nil
+}
+
+// sqliteColumns читает колонки и первичный ключ
+func sqliteColumns(ctx context.Context, db *sql.DB, t *shema.Table) error {
+	type pk struct {
+		pos  int
+		name string
+	}
+	var pks []pk
+	if err := eachRow(ctx, db, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?, ?) ORDER BY cid`, func(rows *sql.Rows) error {
+		var col shema.TableColumn
+		var notNull bool
+		var def sql.NullString
+		var pkPos int
+		if err := rows.Scan(&col.Name, &col.Type, &notNull, &def, &pkPos); err != nil {
+			return err
+		}
+		col.Nullable = !notNull
+		col.Default = nullString(def)
+		t.Columns = append(t.Columns, col)
+		if pkPos > 0 {
+			pks = append(pks, pk{pkPos, col.Name})
+		}
+		return nil
+	}, t.Name, t.Schema); err != nil {
+		return err
+	}
+	for pos := 1; pos <= len(pks); pos++ {
+		for _, p := range pks {
+			if p.pos == pos {
+				t.PrimaryKey = append(t.PrimaryKey, p.name)
+			}
+		}
+	}
+	return nil
+}
+
+// sqliteEstimates берет число строк из sqlite_stat1, которую заполняет ANALYZE; без нее оценок нет
+func sqliteEstimates(ctx context.Context, db *sql.DB, schema string) map[string]int64 {
+	res := make(map[string]int64)
+	eachRow(ctx, db, "SELECT tbl, stat FROM "+dialect.SQLite.Quote(schema)+".sqlite_stat1", func(rows *sql.Rows) error {
+		var table, stat string
+		if err := rows.Scan(&table, &stat); err != nil {
+			return err
+		}
+		if _, ok := res[table]; ok {
+			return nil
+		}
+		first, _, _ := strings.Cut(stat, " ")
+		if n, err := strconv.ParseInt(first, 10, 64); err == nil {
+			res[table] = n
+		}
+		return nil
+	})
+	return res
+}
@@ -0,0 +1,16 @@
+package clientip
+
+import "context"
+
+type key struct{}
+
+// With сохраняет адрес клиента в контексте запроса
+func With(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, key{}, ip)
+}
+
+// From возвращает адрес клиента или пустую строку, если его не сохраняли
+func From(ctx context.Context) string {
+	ip, _ := ctx.Value(key{}).(string)
+	return ip
+}
@@ -5,4 +5,13 @@ import "errors"
 var (
 	ErrAlreadyExists = errors.New("invalid data")
 	ErrInvalidData   = errors.New("invalid data")
+	ErrNoConnection  = errors.New("no connections")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrQueryFailed   = errors.New("query failed")
+	ErrReadOnly      = errors.New("connection is read-only")
+	ErrNotFound      = errors.New("not found")
+	ErrQueryTimeout  = errors.New("query timed out")
+	ErrQueryCanceled = errors.New("query canceled")
+	ErrForbidden     = errors.New("forbidden")
+	ErrCSRF          = errors.New("missing or invalid CSRF token")
 )
@@ -0,0 +1,54 @@
+package dialect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect - тип базы данных в том виде, в каком он приходит из формы и хранится в connections.typeDB
+type Dialect string
+
+const (
+	Postgres Dialect = "postgresql"
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+)
+
+// Driver возвращает имя драйвера database/sql
+func (d Dialect) Driver() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case MySQL:
+		return "mysql"
+	case SQLite:
+		return "sqlite3"
+	}
+	return ""
+}
+
+// Quote экранирует идентификатор: `name` в MySQL, "name" в остальных базах
+func (d Dialect) Quote(ident string) string {
+	q := `"`
+	if d == MySQL {
+		q = "`"
+	}
+	return q + strings.ReplaceAll(ident, q, q+q) + q
+}
+
+// QuoteQualified экранирует каждую часть имени вида schema.table
+func (d Dialect) QuoteQualified(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = d.Quote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder возвращает n-й (с 1) параметр запроса: $n для Postgres, ? для остальных
+func (d Dialect) Placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
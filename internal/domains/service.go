@@ -2,21 +2,61 @@ package domains
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
+	"smartTables/internal/shema"
 )
 
 type Service interface {
-	ExecQuery(ctx context.Context, query string, user string) ([][]string, error)
+	ExecQuery(ctx context.Context, user string, req shema.QueryRequest) (*shema.QueryResult, error)
+	QueryParams(user, connID, query string) ([]shema.QueryParam, error)
 	Registration(ctx context.Context, user, password string) error
 	Login(ctx context.Context, user, password string) error
-	GetConnection(ctx context.Context, user, typeDB, connect, dbName string)
-	GetConnectionWithFile(user, typeDB, dbName string, file *multipart.FileHeader)
-	GetConnectionFromBtn(ctx context.Context, user, connect, dbName string) (string, error)
-	GetTables(ctx context.Context, user string) ([]string, error)
-	QueryFromFile(ctx context.Context, file *multipart.FileHeader, user string) ([][]string, error)
+	GetConnection(ctx context.Context, user, typeDB, connect, dbName string, readOnly bool, teamID int64) (shema.Connection, error)
+	GetConnectionWithFile(ctx context.Context, user, typeDB, dbName string, file *multipart.FileHeader, readOnly bool) (shema.Connection, error)
+	GetConnectionFromBtn(ctx context.Context, user string, id int64) (shema.Connection, error)
+	ListConnections(user string) []shema.Connection
+	CloseConnection(user, connID string) error
+	GetTables(ctx context.Context, user, connID string) ([]string, error)
+	GetSchema(ctx context.Context, user, connID string) ([]shema.DBSchema, error)
+	QueryFromFile(ctx context.Context, file *multipart.FileHeader, user, connID string, opts shema.ScriptOptions) (*shema.ScriptResult, error)
 	Logout(user string) error
-	SaveQuery(ctx context.Context, query, user string) error
-	GetHistory(ctx context.Context, user string) ([][]string, error)
-	Switch(user, typeDB string) error
-	GetLastDB(ctx context.Context, user string) (map[string]string, error)
+	GetHistory(ctx context.Context, user string, filter shema.HistoryFilter) (*shema.HistoryPage, error)
+	HistoryDatabases(ctx context.Context, user string) ([]shema.HistoryDatabase, error)
+	Switch(user, connID string) error
+	GetLastDB(ctx context.Context, user string) ([]shema.SavedConnection, error)
+	PoolCount() int
+	RunningQueries(user string) []shema.RunningQuery
+	CancelQuery(user, queryID string) error
+	PreviewImport(ctx context.Context, user string, file *multipart.FileHeader, opts shema.ImportOptions) (*shema.ImportPreview, error)
+	Import(ctx context.Context, user string, req shema.ImportRequest) (*shema.ImportResult, error)
+	Export(ctx context.Context, user string, req shema.QueryRequest, opts shema.ExportOptions, open func() io.Writer) error
+	TableData(ctx context.Context, user string, req shema.GridRequest) (*shema.GridResult, error)
+	PreviewEdit(ctx context.Context, user string, edit shema.RowEdit) (*shema.EditStatement, error)
+	ApplyEdit(ctx context.Context, user string, edit shema.RowEdit) (*shema.EditStatement, int64, error)
+	ListSavedQueries(ctx context.Context, user string, filter shema.SavedQueryFilter) ([]shema.SavedQuery, error)
+	GetSavedQuery(ctx context.Context, user string, id int64) (shema.SavedQuery, error)
+	StoreSavedQuery(ctx context.Context, user string, q shema.SavedQuery) (int64, error)
+	DeleteSavedQuery(ctx context.Context, user string, id int64) error
+	RunSavedQuery(ctx context.Context, user string, id int64, req shema.QueryRequest) (*shema.QueryResult, shema.QueryRequest, error)
+	UserRole(ctx context.Context, user string) (string, error)
+	IsAdmin(ctx context.Context, user string) bool
+	ListUsers(ctx context.Context, admin string) ([]shema.User, error)
+	SetUserRole(ctx context.Context, admin, user, role string) error
+	ListConnectionAccess(ctx context.Context, admin string) ([]shema.ConnectionAccess, error)
+	GrantConnection(ctx context.Context, admin string, g shema.ConnectionGrant) error
+	RevokeConnection(ctx context.Context, admin string, g shema.ConnectionGrant) error
+	UserTeams(ctx context.Context, user string) ([]shema.Team, error)
+	ListTeams(ctx context.Context, admin string) ([]shema.Team, error)
+	CreateTeam(ctx context.Context, admin, name string) (int64, error)
+	DeleteTeam(ctx context.Context, admin string, id int64) error
+	AddTeamMember(ctx context.Context, admin string, m shema.TeamMember) error
+	RemoveTeamMember(ctx context.Context, admin string, m shema.TeamMember) error
+	LoadSession(ctx context.Context, id string) (shema.Session, error)
+	SaveSession(ctx context.Context, e shema.Session) error
+	DeleteSession(ctx context.Context, id string) error
+	ListSessions(ctx context.Context, user string) ([]shema.Session, error)
+	RevokeSession(ctx context.Context, user, id string) error
+	RevokeUserSessions(ctx context.Context, admin, user string) (int64, error)
+	AuditEvents(ctx context.Context, user string, filter shema.AuditFilter) (*shema.AuditPage, error)
 }
@@ -2,15 +2,42 @@ package domains
 
 import (
 	"context"
-	"time"
+	"smartTables/internal/shema"
 )
 
 type Storage interface {
 	Registration(ctx context.Context, user string, password []byte) error
 	Login(ctx context.Context, user string) ([]byte, error)
-	SaveQuery(ctx context.Context, user, typeDB, dbName, query string, time time.Time) error
-	GetHistory(ctx context.Context, user, dbName string) ([][]string, error)
-	GetLastDB(ctx context.Context, user string) (map[string]string, error)
-	SaveConnection(ctx context.Context, user, typeDB, dbname, connectionString string) error
-	GetTypeDB(ctx context.Context, user, dbName, connStr string) (string, error)
+	SaveQuery(ctx context.Context, user string, e shema.HistoryEntry) error
+	GetHistory(ctx context.Context, user string, filter shema.HistoryFilter) ([]shema.HistoryEntry, error)
+	GetHistoryDatabases(ctx context.Context, user string) ([]shema.HistoryDatabase, error)
+	GetLastDB(ctx context.Context, user string) ([]shema.SavedConnection, error)
+	SaveConnection(ctx context.Context, user, typeDB, dbname, connectionString string, readOnly bool, teamID int64) (int64, error)
+	GetSavedConnection(ctx context.Context, user string, id int64) (shema.SavedConnection, error)
+	GetAllConnectionSecrets(ctx context.Context) ([]shema.SavedConnection, error)
+	UpdateConnectionSecret(ctx context.Context, id int64, connectionString string) error
+	ListSavedQueries(ctx context.Context, user string, filter shema.SavedQueryFilter) ([]shema.SavedQuery, error)
+	GetSavedQuery(ctx context.Context, user string, id int64) (shema.SavedQuery, error)
+	CreateSavedQuery(ctx context.Context, q shema.SavedQuery) (int64, error)
+	UpdateSavedQuery(ctx context.Context, q shema.SavedQuery) error
+	DeleteSavedQuery(ctx context.Context, user string, id int64) error
+	SaveAuditEvent(ctx context.Context, e shema.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter shema.AuditFilter) ([]shema.AuditEvent, error)
+	GetUserRole(ctx context.Context, user string) (string, error)
+	ListUsers(ctx context.Context) ([]shema.User, error)
+	SetUserRole(ctx context.Context, user, role string) error
+	ListConnectionAccess(ctx context.Context) ([]shema.ConnectionAccess, error)
+	GrantConnection(ctx context.Context, g shema.ConnectionGrant) error
+	RevokeConnection(ctx context.Context, g shema.ConnectionGrant) error
+	ListTeams(ctx context.Context) ([]shema.Team, error)
+	UserTeams(ctx context.Context, user string) ([]shema.Team, error)
+	CreateTeam(ctx context.Context, name string) (int64, error)
+	DeleteTeam(ctx context.Context, id int64) error
+	AddTeamMember(ctx context.Context, m shema.TeamMember) error
+	RemoveTeamMember(ctx context.Context, m shema.TeamMember) error
+	LoadSession(ctx context.Context, id string) (shema.Session, error)
+	SaveSession(ctx context.Context, e shema.Session) error
+	DeleteSession(ctx context.Context, login, id string) error
+	DeleteUserSessions(ctx context.Context, login string) (int64, error)
+	ListSessions(ctx context.Context, login string) ([]shema.Session, error)
 }
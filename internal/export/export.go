@@ -0,0 +1,116 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"smartTables/internal/shema"
+	"unicode/utf8"
+)
+
+const (
+	CSV   = "csv"
+	TSV   = "tsv"
+	JSONL = "jsonl"
+	XLSX  = "xlsx"
+)
+
+// Writer пишет строки результата в выбранном формате, не накапливая их в памяти
+type Writer interface {
+	WriteHeader(columns []shema.Column) error
+	WriteRow(row []shema.Cell) error
+	// Close дописывает формат до конца, сам io.Writer не закрывает
+	Close() error
+}
+
+// Normalize подставляет формат и разделитель по умолчанию и проверяет опции
+func Normalize(opts shema.ExportOptions) (shema.ExportOptions, error) {
+	if opts.Format == "" {
+		opts.Format = CSV
+	}
+	switch opts.Format {
+	case CSV:
+		if opts.Delimiter == "" {
+			opts.Delimiter = ","
+		}
+	case TSV:
+		if opts.Delimiter == "" {
+			opts.Delimiter = "\t"
+		}
+	case JSONL, XLSX:
+		return opts, nil
+	default:
+		return opts, fmt.Errorf("unknown export format %q", opts.Format)
+	}
+	if opts.Delimiter == `\t` || opts.Delimiter == "tab" {
+		opts.Delimiter = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(opts.Delimiter)
+	if size != len(opts.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return opts, fmt.Errorf("bad delimiter %q", opts.Delimiter)
+	}
+	return opts, nil
+}
+
+// New создает Writer для опций, прошедших Normalize
+func New(w io.Writer, opts shema.ExportOptions) (Writer, error) {
+	opts, err := Normalize(opts)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Format {
+	case JSONL:
+		return newJSONL(w), nil
+	case XLSX:
+		return newXLSX(w, opts.Null)
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma, _ = utf8.DecodeRuneInString(opts.Delimiter)
+	return &csvWriter{w: cw, null: opts.Null}, nil
+}
+
+func ContentType(format string) string {
+	switch format {
+	case TSV:
+		return "text/tab-separated-values; charset=utf-8"
+	case JSONL:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	null string
+	rec  []string
+}
+
+func (c *csvWriter) WriteHeader(columns []shema.Column) error {
+	rec := make([]string, len(columns))
+	for i, col := range columns {
+		rec[i] = col.Name
+	}
+	return c.w.Write(rec)
+}
+
+func (c *csvWriter) WriteRow(row []shema.Cell) error {
+	if cap(c.rec) < len(row) {
+		c.rec = make([]string, len(row))
+	}
+	rec := c.rec[:len(row)]
+	for i, cell := range row {
+		if cell.Null {
+			rec[i] = c.null
+		} else {
+			rec[i] = cell.Value
+		}
+	}
+	return c.w.Write(rec)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"smartTables/internal/shema"
+)
+
+// jsonlWriter пишет каждую строку отдельным JSON-объектом, порядок колонок сохраняется
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func newJSONL(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonlWriter) WriteHeader(columns []shema.Column) error {
+	j.keys = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := json.Marshal(col.Name)
+		if err != nil {
+			return err
+		}
+		j.keys[i] = key
+	}
+	return nil
+}
+
+func (j *jsonlWriter) WriteRow(row []shema.Cell) error {
+	j.w.WriteByte('{')
+	for i, cell := range row {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteByte(':')
+		value, err := cell.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		j.w.Write(value)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
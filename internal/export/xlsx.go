@@ -0,0 +1,126 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"smartTables/internal/shema"
+	"strconv"
+	"time"
+)
+
+// xlsxMaxRows - предел строк листа Excel
+const xlsxMaxRows = 1048576
+
+// xlsxWriter пишет книгу с одним листом прямо в zip-поток: служебные части известны заранее,
+// лист идет последним и пишется построчно
+type xlsxWriter struct {
+	zip  *zip.Writer
+	w    *bufio.Writer
+	null string
+	rows int
+}
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Result" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func newXLSX(w io.Writer, null string) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zip: z, w: bufio.NewWriter(sheet), null: null}
+	x.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x, nil
+}
+
+func (x *xlsxWriter) WriteHeader(columns []shema.Column) error {
+	row := make([]shema.Cell, len(columns))
+	for i, col := range columns {
+		row[i] = shema.Cell{Value: col.Name}
+	}
+	return x.WriteRow(row)
+}
+
+func (x *xlsxWriter) WriteRow(row []shema.Cell) error {
+	if x.rows == xlsxMaxRows {
+		return errors.New("too many rows for xlsx")
+	}
+	x.rows++
+	x.w.WriteString("<row>")
+	for _, cell := range row {
+		switch v := cell.Raw.(type) {
+		case int64:
+			x.number(strconv.FormatInt(v, 10))
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				x.text(cell.Value)
+				continue
+			}
+			x.number(strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			if v {
+				x.w.WriteString(`<c t="b"><v>1</v></c>`)
+			} else {
+				x.w.WriteString(`<c t="b"><v>0</v></c>`)
+			}
+		case time.Time:
+			x.text(cell.Value)
+		default:
+			if cell.Null {
+				if x.null == "" {
+					x.w.WriteString("<c/>")
+				} else {
+					x.text(x.null)
+				}
+				continue
+			}
+			x.text(cell.Value)
+		}
+	}
+	_, err := x.w.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxWriter) number(v string) {
+	x.w.WriteString("<c><v>")
+	x.w.WriteString(v)
+	x.w.WriteString("</v></c>")
+}
+
+func (x *xlsxWriter) text(v string) {
+	x.w.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	// EscapeText заменяет недопустимые в XML символы
+	xml.EscapeText(x.w, []byte(v))
+	x.w.WriteString("</t></is></c>")
+}
+
+func (x *xlsxWriter) Close() error {
+	x.w.WriteString("</sheetData></worksheet>")
+	if err := x.w.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strconv"
+)
+
+// requireRole пускает к обработчику только пользователей с ролью не ниже min; ставится за authRequired.
+// Роль читается при каждом запросе, поэтому ее изменение действует сразу.
+func (s *Handler) requireRole(min string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := s.service.UserRole(c.Request.Context(), currentUser(c).Login)
+		if err == nil && !shema.RoleAllows(role, min) {
+			err = fmt.Errorf("%w: %s role required", constants.ErrForbidden, min)
+		}
+		if err != nil {
+			if wantsJSON(c) {
+				APIErr(c, err)
+				return
+			}
+			HandlerErr(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Handler) AdminUsers(c *gin.Context) {
+	login := currentUser(c).Login
+
+	ctx := c.Request.Context()
+	users, err := s.service.ListUsers(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	conns, err := s.service.ListConnectionAccess(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	teams, err := s.service.ListTeams(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "users.html", gin.H{
+		"users":       users,
+		"connections": conns,
+		"teams":       teams,
+		"sessions":    s.config.SessionStore == sessionStorePostgres,
+		"roles":       shema.Roles,
+		"login":       login,
+		"error":       c.Query("error"),
+	})
+}
+
+// adminRedirect возвращает на страницу пользователей; ошибку показываем там же, а не отдельным ответом
+func adminRedirect(c *gin.Context, err error) {
+	target := "/admin/users"
+	if err != nil {
+		target += "?error=" + url.QueryEscape(err.Error())
+	}
+	c.Redirect(http.StatusSeeOther, target)
+}
+
+func (s *Handler) AdminSetRole(c *gin.Context) {
+	login := currentUser(c).Login
+
+	err := s.service.SetUserRole(c.Request.Context(), login, c.PostForm("login"), c.PostForm("role"))
+	adminRedirect(c, err)
+}
+
+func (s *Handler) AdminGrant(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var g shema.ConnectionGrant
+	if err := c.ShouldBind(&g); err != nil {
+		adminRedirect(c, err)
+		return
+	}
+	var err error
+	if c.PostForm("revoke") == "true" {
+		err = s.service.RevokeConnection(c.Request.Context(), login, g)
+	} else {
+		err = s.service.GrantConnection(c.Request.Context(), login, g)
+	}
+	adminRedirect(c, err)
+}
+
+func (s *Handler) APIUsers(c *gin.Context) {
+	login := currentUser(c).Login
+
+	users, err := s.service.ListUsers(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+func (s *Handler) APISetRole(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	if err := s.service.SetUserRole(c.Request.Context(), login, c.Param("login"), req.Role); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shema.User{Login: c.Param("login"), Role: req.Role})
+}
+
+func (s *Handler) APIGrants(c *gin.Context) {
+	login := currentUser(c).Login
+
+	res, err := s.service.ListConnectionAccess(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"connections": res})
+}
+
+func (s *Handler) APIGrant(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var g shema.ConnectionGrant
+	if err := c.ShouldBindJSON(&g); err != nil {
+		APIErr(c, err)
+		return
+	}
+	if err := s.service.GrantConnection(c.Request.Context(), login, g); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, g)
+}
+
+func (s *Handler) APIRevoke(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	g := shema.ConnectionGrant{ConnectionID: id, Login: c.Param("login")}
+	if err := s.service.RevokeConnection(c.Request.Context(), login, g); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
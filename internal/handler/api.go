@@ -0,0 +1,364 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+)
+
+type apiCredentials struct {
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type apiConnectionRequest struct {
+	Database         string `json:"database" form:"database" binding:"required"`
+	DBName           string `json:"dbName" form:"dbName"`
+	ConnectionString string `json:"connectionString" form:"connectionString"`
+	ReadOnly         bool   `json:"readOnly" form:"readOnly"`
+	TeamID           int64  `json:"teamId" form:"team"`
+}
+
+type apiSwitchRequest struct {
+	ConnectionID string `json:"connectionId" binding:"required"`
+}
+
+type apiQueryResponse struct {
+	Columns   []shema.Column `json:"columns"`
+	Rows      [][]shema.Cell `json:"rows"`
+	RowCount  int            `json:"rowCount"`
+	Offset    int            `json:"offset"`
+	Limit     int            `json:"limit"`
+	Truncated bool           `json:"truncated"`
+	Pageable  bool           `json:"pageable"`
+	// RowsAffected - число измененных строк у INSERT, UPDATE, DELETE и других операторов без результата
+	RowsAffected *int64 `json:"rowsAffected,omitempty"`
+	// NextOffset - offset следующей страницы, если она есть
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+func (s *Handler) APIRegistration(c *gin.Context) {
+	var req apiCredentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	err := s.service.Registration(c.Request.Context(), req.Login, req.Password)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"login": req.Login})
+}
+
+func (s *Handler) APILogin(c *gin.Context) {
+	var req apiCredentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	err := s.service.Login(c.Request.Context(), req.Login, req.Password)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	token, err := s.startSession(c, req.Login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"login": req.Login, "csrfToken": token})
+}
+
+func (s *Handler) APILogout(c *gin.Context) {
+	login := currentUser(c).Login
+
+	err := s.service.Logout(login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Clear()
+	session.Save()
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APIConnections(c *gin.Context) {
+	login := currentUser(c).Login
+
+	res, err := s.service.GetLastDB(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"connections": res})
+}
+
+func (s *Handler) APIConnectSaved(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	conn, err := s.service.GetConnectionFromBtn(c.Request.Context(), login, id)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Set("database", conn.TypeDB)
+	session.Set("connection", conn.ID)
+	session.Save()
+
+	c.JSON(http.StatusCreated, conn)
+}
+
+func (s *Handler) APIConnect(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req apiConnectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	db := strings.ToLower(req.Database)
+
+	var conn shema.Connection
+	var err error
+	if db == "sqlite" {
+		file, ferr := c.FormFile("sqliteDbFile")
+		if ferr != nil {
+			APIErr(c, ferr)
+			return
+		}
+		conn, err = s.service.GetConnectionWithFile(c.Request.Context(), login, db, req.DBName, file, req.ReadOnly)
+	} else {
+		if req.ConnectionString == "" {
+			APIErr(c, errors.New("connectionString is required"))
+			return
+		}
+		conn, err = s.service.GetConnection(c.Request.Context(), login, db, req.ConnectionString, req.DBName, req.ReadOnly, req.TeamID)
+	}
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Set("database", db)
+	session.Set("connection", conn.ID)
+	session.Save()
+
+	c.JSON(http.StatusCreated, conn)
+}
+
+func (s *Handler) APIOpenConnections(c *gin.Context) {
+	login := currentUser(c).Login
+
+	c.JSON(http.StatusOK, gin.H{"connections": s.service.ListConnections(login)})
+}
+
+func (s *Handler) APICloseConnection(c *gin.Context) {
+	login := currentUser(c).Login
+
+	connID := c.Param("id")
+	err := s.service.CloseConnection(login, connID)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	if session.Get("connection") == connID {
+		session.Delete("database")
+		session.Delete("connection")
+		session.Save()
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APISwitch(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req apiSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	err := s.service.Switch(login, req.ConnectionID)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	session := sessions.Default(c)
+	if session.Get("connection") == req.ConnectionID {
+		session.Delete("database")
+		session.Delete("connection")
+		session.Save()
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APITables(c *gin.Context) {
+	login := currentUser(c).Login
+
+	data, err := s.service.GetTables(c.Request.Context(), login, c.Query("connectionId"))
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	if data == nil {
+		data = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tables": data})
+}
+
+// APISchema отдает полное дерево схем, например для автодополнения в редакторе
+func (s *Handler) APISchema(c *gin.Context) {
+	login := currentUser(c).Login
+
+	schemas, err := s.service.GetSchema(c.Request.Context(), login, c.Query("connectionId"))
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schemas": schemas})
+}
+
+func (s *Handler) APIQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req shema.QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := s.service.ExecQuery(ctx, login, req)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAPIQueryResponse(res))
+}
+
+type apiParamsRequest struct {
+	Query  string `json:"query" binding:"required"`
+	ConnID string `json:"connectionId"`
+}
+
+// APIQueryParams возвращает параметры :name и {{name}}, найденные в тексте запроса
+func (s *Handler) APIQueryParams(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req apiParamsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	params, err := s.service.QueryParams(login, req.ConnID, req.Query)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"params": params})
+}
+
+func (s *Handler) APIQueryFile(c *gin.Context) {
+	login := currentUser(c).Login
+
+	file, err := c.FormFile("fileUpload")
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	var opts shema.ScriptOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		APIErr(c, err)
+		return
+	}
+	res, err := s.service.QueryFromFile(c.Request.Context(), file, login, c.PostForm("connectionId"), opts)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Handler) APIHistory(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var filter shema.HistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		APIErr(c, err)
+		return
+	}
+	page, err := s.service.GetHistory(c.Request.Context(), login, filter)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func newAPIQueryResponse(res *shema.QueryResult) apiQueryResponse {
+	if res.Columns == nil {
+		return apiQueryResponse{Columns: []shema.Column{}, Rows: [][]shema.Cell{}, RowsAffected: &res.RowsAffected}
+	}
+	resp := apiQueryResponse{
+		Columns:   res.Columns,
+		Rows:      res.Rows,
+		RowCount:  len(res.Rows),
+		Offset:    res.Offset,
+		Limit:     res.Limit,
+		Truncated: res.Truncated,
+		Pageable:  res.Pageable,
+	}
+	if res.Pageable && res.Truncated {
+		next := res.Offset + len(res.Rows)
+		resp.NextOffset = &next
+	}
+	return resp
+}
+
+func (s *Handler) APIRunningQueries(c *gin.Context) {
+	login := currentUser(c).Login
+
+	c.JSON(http.StatusOK, gin.H{"queries": s.service.RunningQueries(login)})
+}
+
+func (s *Handler) APICancelQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	err := s.service.CancelQuery(login, c.Param("id"))
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+func (s *Handler) APIPools(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"open": s.service.PoolCount()})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"smartTables/internal/domains"
+	"smartTables/internal/shema"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryService отвечает на ExecQuery заранее заданным результатом
+type queryService struct {
+	domains.Service
+	res *shema.QueryResult
+	req shema.QueryRequest
+}
+
+func (s *queryService) ExecQuery(ctx context.Context, user string, req shema.QueryRequest) (*shema.QueryResult, error) {
+	s.req = req
+	return s.res, nil
+}
+
+func serveAPIQuery(t *testing.T, svc domains.Service, body string) map[string]interface{} {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: svc}
+	r := gin.New()
+	r.POST("/api/v1/query", func(c *gin.Context) { c.Set(principalKey, Principal{Login: "alice"}) }, h.APIQuery)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200; body %s", w.Code, w.Body)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAPIQueryDML(t *testing.T) {
+	svc := &queryService{res: &shema.QueryResult{Rows: [][]shema.Cell{}, RowsAffected: 3}}
+	resp := serveAPIQuery(t, svc, `{"query": "DELETE FROM t WHERE id < 4"}`)
+
+	if svc.req.Query != "DELETE FROM t WHERE id < 4" {
+		t.Fatalf("query: got %q", svc.req.Query)
+	}
+	if resp["rowsAffected"] != float64(3) {
+		t.Fatalf("rowsAffected: got %v, want 3", resp["rowsAffected"])
+	}
+	if cols, ok := resp["columns"].([]interface{}); !ok || len(cols) != 0 {
+		t.Fatalf("columns: got %v, want []", resp["columns"])
+	}
+}
+
+func TestAPIQuerySelect(t *testing.T) {
+	svc := &queryService{res: &shema.QueryResult{
+		Columns: []shema.Column{{Name: "id"}},
+		Rows:    [][]shema.Cell{{{Value: "1", Raw: int64(1)}}},
+	}}
+	resp := serveAPIQuery(t, svc, `{"query": "SELECT id FROM t"}`)
+
+	if _, ok := resp["rowsAffected"]; ok {
+		t.Fatalf("rowsAffected must be omitted for SELECT, got %v", resp["rowsAffected"])
+	}
+	if resp["rowCount"] != float64(1) {
+		t.Fatalf("rowCount: got %v, want 1", resp["rowCount"])
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"smartTables/internal/shema"
+	"strconv"
+)
+
+func (s *Handler) AuditLog(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var filter shema.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	page, err := s.service.AuditEvents(c.Request.Context(), login, filter)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "audit.html", gin.H{
+		"audit":   page,
+		"filter":  filter,
+		"actions": shema.AuditActions,
+		"links": pageLinks(page.Offset, page.Limit, page.HasMore, func(offset int) string {
+			f := filter
+			f.Offset = offset
+			return auditURL(f)
+		}),
+	})
+}
+
+func auditURL(f shema.AuditFilter) string {
+	v := url.Values{}
+	for key, value := range map[string]string{"actor": f.Actor, "action": f.Action, "dbName": f.DBName, "queryHash": f.QueryHash, "from": f.From, "to": f.To} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.Offset > 0 {
+		v.Set("offset", strconv.Itoa(f.Offset))
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return "/admin/audit?" + v.Encode()
+}
+
+func (s *Handler) APIAuditLog(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var filter shema.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		APIErr(c, err)
+		return
+	}
+	page, err := s.service.AuditEvents(c.Request.Context(), login, filter)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
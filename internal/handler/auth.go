@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/constants"
+	"strings"
+)
+
+// Principal - пользователь, от имени которого выполняется запрос
+type Principal struct {
+	Login string
+}
+
+const principalKey = "principal"
+
+// authRequired пускает к защищенным маршрутам только вошедших пользователей и кладет
+// Principal в контекст gin. Клиентам API и тем, кто ждет JSON, отвечает 401, браузеру -
+// переходом на страницу входа.
+func authRequired(c *gin.Context) {
+	session := sessions.Default(c)
+	login, ok := session.Get("login").(string)
+	if session.Get("authenticated") != true || !ok || login == "" {
+		if wantsJSON(c) {
+			APIErr(c, constants.ErrUnauthorized)
+			return
+		}
+		c.Redirect(http.StatusFound, "/login")
+		c.Abort()
+		return
+	}
+	c.Set(principalKey, Principal{Login: login})
+	c.Next()
+}
+
+// currentUser возвращает пользователя запроса; вызывать только за authRequired
+func currentUser(c *gin.Context) Principal {
+	return c.MustGet(principalKey).(Principal)
+}
+
+// wantsJSON сообщает, что ответ нужен в JSON: запрос к API или клиент предпочитает JSON, а не HTML
+func wantsJSON(c *gin.Context) bool {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		return true
+	}
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
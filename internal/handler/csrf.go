@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/constants"
+)
+
+const (
+	csrfKey    = "csrf"
+	csrfField  = "csrf_token"
+	csrfHeader = "X-CSRF-Token"
+)
+
+// csrfToken возвращает CSRF-токен сессии, при первом обращении создает и сохраняет его
+func csrfToken(c *gin.Context) (string, error) {
+	session := sessions.Default(c)
+	if token, ok := session.Get(csrfKey).(string); ok && token != "" {
+		return token, nil
+	}
+	token, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	session.Set(csrfKey, token)
+	if err := session.Save(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// csrfProtect проверяет запросы, меняющие состояние: токен из поля формы csrf_token или
+// заголовка X-CSRF-Token должен совпасть с токеном сессии. Чужая страница cookie отправить
+// может, а токен прочитать - нет.
+func csrfProtect(c *gin.Context) {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		c.Next()
+		return
+	}
+
+	want, _ := sessions.Default(c).Get(csrfKey).(string)
+	got := c.GetHeader(csrfHeader)
+	if got == "" {
+		got = c.PostForm(csrfField)
+	}
+	if want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		if wantsJSON(c) {
+			APIErr(c, constants.ErrCSRF)
+			return
+		}
+		HandlerErr(c, constants.ErrCSRF)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// render отдает HTML-шаблон, добавляя в данные CSRF-токен: формы шаблонов кладут его в поле csrf_token
+func render(c *gin.Context, code int, name string, data gin.H) {
+	token, err := csrfToken(c)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	if data == nil {
+		data = gin.H{}
+	}
+	data["csrf"] = token
+	c.HTML(code, name, data)
+}
+
+// APICSRF выдает CSRF-токен клиентам API: его передают в заголовке X-CSRF-Token,
+// в том числе при входе
+func (s *Handler) APICSRF(c *gin.Context) {
+	token, err := csrfToken(c)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"csrfToken": token})
+}
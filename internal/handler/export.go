@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime"
+	"net/http"
+	"smartTables/internal/export"
+	"smartTables/internal/shema"
+	"time"
+)
+
+type exportRequest struct {
+	shema.QueryRequest
+	shema.ExportOptions
+}
+
+func (s *Handler) Export(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	var req exportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	req.ConnID = connectionID(c, session)
+	req.Params = queryParams(c)
+	opts, err := export.Normalize(req.ExportOptions)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	err = s.service.Export(c.Request.Context(), login, req.QueryRequest, opts, startExport(c, opts.Format))
+	if err != nil && !c.Writer.Written() {
+		HandlerErr(c, err)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+func (s *Handler) APIExport(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req exportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	opts, err := export.Normalize(req.ExportOptions)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	err = s.service.Export(c.Request.Context(), login, req.QueryRequest, opts, startExport(c, opts.Format))
+	if err != nil && !c.Writer.Written() {
+		APIErr(c, err)
+		return
+	}
+	if err != nil {
+		// ответ уже начат, клиент получит оборванный файл
+		c.Error(err)
+	}
+}
+
+// startExport выставляет заголовки файла непосредственно перед первой записью
+func startExport(c *gin.Context, format string) func() io.Writer {
+	return func() io.Writer {
+		filename := fmt.Sprintf("export-%s.%s", time.Now().Format("20060102-150405"), format)
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Status(http.StatusOK)
+		return c.Writer
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+)
+
+const defaultGridLimit = 50
+
+// gridURL собирает адрес страницы грида, сохраняя сортировку и фильтры
+func gridURL(req shema.GridRequest) string {
+	v := url.Values{}
+	if req.ConnID != "" {
+		v.Set("connection", req.ConnID)
+	}
+	if req.Schema != "" {
+		v.Set("schema", req.Schema)
+	}
+	v.Set("table", req.Table)
+	if req.Offset > 0 {
+		v.Set("offset", strconv.Itoa(req.Offset))
+	}
+	v.Set("limit", strconv.Itoa(req.Limit))
+	if req.Sort != "" {
+		v.Set("sort", req.Sort)
+		if req.Desc {
+			v.Set("desc", "true")
+		}
+	}
+	for _, f := range req.Filters {
+		v.Add("fcol", f.Column)
+		v.Add("fop", f.Op)
+		v.Add("fval", f.Value)
+	}
+	return "/tables/data?" + v.Encode()
+}
+
+// gridFilters собирает фильтры из параллельных массивов fcol, fop и fval; строки без колонки пропускаются
+func gridFilters(cols, ops, values []string) []shema.GridFilter {
+	var res []shema.GridFilter
+	for i, col := range cols {
+		if col == "" {
+			continue
+		}
+		f := shema.GridFilter{Column: col, Op: shema.FilterEq}
+		if i < len(ops) && ops[i] != "" {
+			f.Op = ops[i]
+		}
+		if i < len(values) {
+			f.Value = values[i]
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// formatArgs показывает параметры запроса так, как их увидит база
+func formatArgs(args []interface{}) []string {
+	res := make([]string, len(args))
+	for i, a := range args {
+		if a == nil {
+			res[i] = "NULL"
+		} else {
+			res[i] = fmt.Sprintf("%q", a)
+		}
+	}
+	return res
+}
+
+func (s *Handler) TableData(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	var req shema.GridRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	req.ConnID = connectionID(c, session)
+	req.Filters = gridFilters(c.QueryArray("fcol"), c.QueryArray("fop"), c.QueryArray("fval"))
+	if req.Limit == 0 {
+		req.Limit = defaultGridLimit
+	}
+	grid, err := s.service.TableData(c.Request.Context(), login, req)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	// ссылки сортировки: повторный клик по колонке меняет направление
+	sortLinks := make(map[string]string, len(grid.Table.Columns))
+	for _, col := range grid.Table.Columns {
+		r := req
+		r.Offset = 0
+		r.Sort, r.Desc = col.Name, req.Sort == col.Name && !req.Desc
+		sortLinks[col.Name] = gridURL(r)
+	}
+	pk := make(map[string]bool, len(grid.Table.PrimaryKey))
+	for _, col := range grid.Table.PrimaryKey {
+		pk[col] = true
+	}
+	res := grid.Result
+	page := gin.H{"From": res.Offset + 1, "To": res.Offset + len(res.Rows)}
+	if res.Offset > 0 {
+		prev := req
+		prev.Offset = res.Offset - res.Limit
+		if prev.Offset < 0 {
+			prev.Offset = 0
+		}
+		page["Prev"] = gridURL(prev)
+	}
+	if res.Truncated {
+		next := req
+		next.Offset = res.Offset + res.Limit
+		page["Next"] = gridURL(next)
+	}
+
+	// пустая строка в конце формы - для нового фильтра
+	filters := append(append([]shema.GridFilter{}, req.Filters...), shema.GridFilter{})
+
+	render(c, http.StatusOK, "grid.html", gin.H{
+		"grid":      grid,
+		"request":   req,
+		"self":      gridURL(req),
+		"sortLinks": sortLinks,
+		"pk":        pk,
+		"page":      page,
+		"ops":       shema.FilterOps,
+		"filters":   filters,
+		"editable":  !grid.ReadOnly && grid.Table.Kind == shema.TableKindTable && len(grid.Table.PrimaryKey) > 0,
+	})
+}
+
+// TableEdit показывает запрос для изменения строки, а после подтверждения (apply) выполняет его
+func (s *Handler) TableEdit(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	apply := c.PostForm("apply") != ""
+	edit := shema.RowEdit{
+		ConnID: connectionID(c, session),
+		Schema: c.PostForm("schema"),
+		Table:  c.PostForm("table"),
+		Action: c.PostForm("action"),
+		Key:    make(map[string]*string),
+		Values: make(map[string]*string),
+	}
+	for col, v := range c.PostFormMap("key") {
+		v := v
+		edit.Key[col] = &v
+	}
+	nulls, orig, origNulls := c.PostFormMap("null"), c.PostFormMap("orig"), c.PostFormMap("orignull")
+	for col, v := range c.PostFormMap("value") {
+		v := v
+		_, isNull := nulls[col]
+		if !apply {
+			// из грида приходят все колонки строки: в update оставляем измененные,
+			// в insert пропускаем пустые, чтобы сработали значения по умолчанию
+			if o, ok := orig[col]; ok && o == v && isNull == (origNulls[col] != "") {
+				continue
+			}
+			if edit.Action == shema.EditInsert && v == "" && !isNull {
+				continue
+			}
+		}
+		if isNull {
+			edit.Values[col] = nil
+		} else {
+			edit.Values[col] = &v
+		}
+	}
+	back := c.PostForm("return")
+	if !strings.HasPrefix(back, "/tables/data?") {
+		back = "/tables"
+	}
+
+	if !apply {
+		stmt, err := s.service.PreviewEdit(c.Request.Context(), login, edit)
+		if err != nil {
+			HandlerErr(c, err)
+			return
+		}
+		render(c, http.StatusOK, "gridEdit.html", gin.H{
+			"edit":      edit,
+			"statement": stmt,
+			"args":      formatArgs(stmt.Args),
+			"return":    back,
+		})
+		return
+	}
+
+	if _, _, err := s.service.ApplyEdit(c.Request.Context(), login, edit); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, back)
+}
+
+func (s *Handler) APITableData(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req shema.GridRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	grid, err := s.service.TableData(c.Request.Context(), login, req)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grid)
+}
+
+type apiEditRequest struct {
+	shema.RowEdit
+	// Preview - только вернуть запрос, не выполняя его
+	Preview bool `json:"preview"`
+}
+
+func (s *Handler) APITableEdit(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req apiEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	if req.Preview {
+		stmt, err := s.service.PreviewEdit(c.Request.Context(), login, req.RowEdit)
+		if err != nil {
+			APIErr(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"statement": stmt})
+		return
+	}
+	stmt, affected, err := s.service.ApplyEdit(c.Request.Context(), login, req.RowEdit)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statement": stmt, "rowsAffected": affected})
+}
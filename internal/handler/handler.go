@@ -1,18 +1,22 @@
 package handler
 
 import (
-	"crypto/rand"
+	"errors"
 	"fmt"
 	createv1 "github.com/ekovv/protosDB/gen/go/creator"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"log"
 	"net/http"
+	"net/url"
 	"smartTables/config"
+	"smartTables/internal/clientip"
+	"smartTables/internal/constants"
 	"smartTables/internal/domains"
+	"smartTables/internal/shema"
+	"strconv"
 	"strings"
 )
 
@@ -20,9 +24,12 @@ type Handler struct {
 	service domains.Service
 	engine  *gin.Engine
 	config  config.Config
+	// cookie - параметры cookie сессии из конфига
+	cookie sessions.Options
 }
 
-func NewHandler(service domains.Service, cnf config.Config) *Handler {
+// NewHandler собирает роутер; ошибка означает неверные настройки в конфиге
+func NewHandler(service domains.Service, cnf config.Config) (*Handler, error) {
 	router := gin.Default()
 	router.LoadHTMLGlob("templates/html/*")
 	h := &Handler{
@@ -31,18 +38,33 @@ func NewHandler(service domains.Service, cnf config.Config) *Handler {
 		config:  cnf,
 	}
 
-	key := make([]byte, 32)
-	_, err := rand.Read(key)
+	err := router.SetTrustedProxies(cnf.TrustedProxies)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return nil, fmt.Errorf("invalid trustedProxies: %w", err)
 	}
 
-	store := cookie.NewStore(key)
+	h.cookie, err = cookieOptions(cnf)
+	if err != nil {
+		return nil, err
+	}
+	store, err := newSessionStore(service, cnf)
+	if err != nil {
+		return nil, err
+	}
+	store.Options(h.cookie)
+	// адрес клиента кладем в контекст до сессий: серверное хранилище пишет его в сессию
+	router.Use(clientIP)
 	router.Use(sessions.Sessions("token", store))
+	router.Use(csrfProtect)
 
 	Route(router, h)
-	return h
+	return h, nil
+}
+
+// clientIP передает адрес клиента сервису через контекст запроса - он пишется в историю выполнения
+func clientIP(c *gin.Context) {
+	c.Request = c.Request.WithContext(clientip.With(c.Request.Context(), c.ClientIP()))
+	c.Next()
 }
 
 func (s *Handler) Start() {
@@ -54,51 +76,83 @@ func (s *Handler) Start() {
 
 func (s *Handler) GetHome(c *gin.Context) {
 	session := sessions.Default(c)
-	if session.Get("authenticated") != true {
-		c.Redirect(http.StatusMovedPermanently, "/login")
-		return
-	}
+	login := currentUser(c).Login
 
-	c.HTML(http.StatusOK, "smartTables.html", nil)
+	render(c, http.StatusOK, "smartTables.html", gin.H{
+		"connections": s.service.ListConnections(login),
+		"current":     connectionID(c, session),
+		"query":       c.Query("query"),
+		"admin":       s.service.IsAdmin(c.Request.Context(), login),
+	})
 }
 
 func (s *Handler) PostHome(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	session := sessions.Default(c)
-	if session.Get("authenticated") != true {
-		c.Redirect(http.StatusMovedPermanently, "/login")
+	login := currentUser(c).Login
+	var req shema.QueryRequest
+	if err := c.ShouldBind(&req); err != nil {
+		HandlerErr(c, err)
 		return
 	}
+	connID := connectionID(c, session)
+	req.ConnID = connID
+	req.Params = queryParams(c)
 
-	query := c.PostForm("query")
-	login := session.Get("login").(string)
-
-	res, err := s.service.ExecQuery(ctx, query, login)
-	if res == nil {
-		c.HTML(http.StatusOK, "smartTables.html", gin.H{
-			"message": "Запрос успешно выполнен",
-		})
-		return
-	}
+	res, err := s.service.ExecQuery(ctx, login, req)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
-
-	err = s.service.SaveQuery(ctx, query, login)
-	if err != nil {
-		HandlerErr(c, err)
+	if res.Columns == nil {
+		render(c, http.StatusOK, "smartTables.html", gin.H{
+			"message":     execMessage(res),
+			"connections": s.service.ListConnections(login),
+			"current":     connID,
+		})
 		return
 	}
 
-	c.HTML(http.StatusOK, "result.html", gin.H{
+	render(c, http.StatusOK, "result.html", gin.H{
 		"data": res,
+		"page": newResultPage(req, res),
 	})
 }
 
+// execMessage - сообщение об операторе без результата с числом измененных строк
+func execMessage(res *shema.QueryResult) string {
+	return fmt.Sprintf("Запрос успешно выполнен, затронуто строк: %d", res.RowsAffected)
+}
+
+// resultPage - переходы на соседние страницы результата для result.html
+type resultPage struct {
+	Request          shema.QueryRequest
+	From, To         int
+	Prev, Next       int
+	HasPrev, HasNext bool
+}
+
+func newResultPage(req shema.QueryRequest, res *shema.QueryResult) *resultPage {
+	if !res.Pageable {
+		return nil
+	}
+	p := &resultPage{
+		Request: req,
+		From:    res.Offset + 1,
+		To:      res.Offset + len(res.Rows),
+		Next:    res.Offset + len(res.Rows),
+		HasPrev: res.Offset > 0,
+		HasNext: res.Truncated,
+	}
+	if p.Prev = res.Offset - res.Limit; p.Prev < 0 {
+		p.Prev = 0
+	}
+	return p
+}
+
 func (s *Handler) GetResult(c *gin.Context) {
-	c.HTML(http.StatusOK, "result.html", nil)
+	render(c, http.StatusOK, "result.html", nil)
 
 }
 
@@ -116,10 +170,10 @@ func (s *Handler) LoginPost(c *gin.Context) {
 		return
 	}
 
-	session.Set("authenticated", true)
-	session.Set("login", login)
-	session.Options(sessions.Options{MaxAge: 60 * 60})
-	session.Save()
+	if _, err := s.startSession(c, login); err != nil {
+		HandlerErr(c, err)
+		return
+	}
 
 	c.Redirect(http.StatusMovedPermanently, "/")
 }
@@ -130,7 +184,7 @@ func (s *Handler) Login(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/")
 		return
 	}
-	c.HTML(http.StatusOK, "login.html", nil)
+	render(c, http.StatusOK, "login.html", nil)
 }
 
 func (s *Handler) RegistrationPost(c *gin.Context) {
@@ -158,50 +212,66 @@ func (s *Handler) Registration(c *gin.Context) {
 		return
 	}
 
-	c.HTML(http.StatusOK, "registration.html", nil)
+	render(c, http.StatusOK, "registration.html", nil)
 }
 
 func (s *Handler) ConnectionGet(c *gin.Context) {
-	session := sessions.Default(c)
-	if session.Get("authenticated") != true {
-		c.Redirect(http.StatusMovedPermanently, "/login")
+	login := currentUser(c).Login
+
+	ctx := c.Request.Context()
+	m, err := s.service.GetLastDB(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
 		return
 	}
-	login := session.Get("login").(string)
-
-	m, err := s.service.GetLastDB(c.Request.Context(), login)
+	role, err := s.service.UserRole(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	teams, err := s.service.UserTeams(ctx, login)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
 
-	c.HTML(http.StatusOK, "connections.html", gin.H{
-		"buttons": m,
+	render(c, http.StatusOK, "connections.html", gin.H{
+		"buttons":   m,
+		"canCreate": shema.RoleAllows(role, shema.RoleEditor),
+		"teams":     teams,
 	})
 }
 
 func (s *Handler) ConnectionPost(c *gin.Context) {
 	session := sessions.Default(c)
-	if session.Get("authenticated") != true {
-		c.Redirect(http.StatusMovedPermanently, "/login")
-		return
-	}
-	login := session.Get("login").(string)
+	login := currentUser(c).Login
 	dbName := c.PostForm("dbName")
 	db := c.PostForm("database")
 	strings.ToLower(db)
 	connectionString := c.PostForm("connectionString")
+	readOnly := c.PostForm("readOnly") == "true"
+	var teamID int64
+	if team := c.PostForm("team"); team != "" {
+		var err error
+		if teamID, err = strconv.ParseInt(team, 10, 64); err != nil {
+			HandlerErr(c, err)
+			return
+		}
+	}
 	button := c.PostForm("button")
-	value := c.PostForm(button)
 	if button != "" {
-		dbName = button
-		connectionString = value
-		typeDB, err := s.service.GetConnectionFromBtn(c.Request.Context(), login, connectionString, dbName)
+		id, err := strconv.ParseInt(button, 10, 64)
+		if err != nil {
+			HandlerErr(c, err)
+			return
+		}
+		conn, err := s.service.GetConnectionFromBtn(c.Request.Context(), login, id)
 		if err != nil {
 			HandlerErr(c, err)
 			return
 		}
-		session.Set("database", typeDB)
+		session.Set("database", conn.TypeDB)
+		session.Set("connection", conn.ID)
 		session.Save()
 		c.Redirect(http.StatusMovedPermanently, "/smartTable")
 		return
@@ -213,41 +283,48 @@ func (s *Handler) ConnectionPost(c *gin.Context) {
 			return
 		}
 
-		s.service.GetConnectionWithFile(login, db, dbName, file)
+		conn, err := s.service.GetConnectionWithFile(c.Request.Context(), login, db, dbName, file, readOnly)
+		if err != nil {
+			HandlerErr(c, err)
+			return
+		}
 		session.Set("database", db)
+		session.Set("connection", conn.ID)
 		session.Save()
 		c.Redirect(http.StatusMovedPermanently, "/smartTable")
 		return
 	}
+	conn, err := s.service.GetConnection(c.Request.Context(), login, db, connectionString, dbName, readOnly, teamID)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
 	session.Set("database", db)
+	session.Set("connection", conn.ID)
 	session.Save()
-	s.service.GetConnection(c.Request.Context(), login, db, connectionString, dbName)
 
 	c.Redirect(http.StatusMovedPermanently, "/smartTable")
 }
 
 func (s *Handler) ShowTables(c *gin.Context) {
 	session := sessions.Default(c)
-	if session.Get("authenticated") != true {
-		c.Redirect(http.StatusMovedPermanently, "/login")
-		return
-	}
-
-	login := session.Get("login").(string)
-	data, err := s.service.GetTables(c.Request.Context(), login)
+	login := currentUser(c).Login
+	connID := connectionID(c, session)
+	schemas, err := s.service.GetSchema(c.Request.Context(), login, connID)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
 
-	c.HTML(http.StatusOK, "allTables.html", gin.H{
-		"data": data,
+	render(c, http.StatusOK, "allTables.html", gin.H{
+		"schemas":    schemas,
+		"connection": connID,
 	})
 }
 
 func (s *Handler) Logout(c *gin.Context) {
 	session := sessions.Default(c)
-	login := session.Get("login").(string)
+	login := currentUser(c).Login
 	err := s.service.Logout(login)
 	if err != nil {
 		HandlerErr(c, err)
@@ -263,59 +340,183 @@ func (s *Handler) GetFile(c *gin.Context) {
 	file, err := c.FormFile("fileUpload")
 	if err != nil {
 		HandlerErr(c, err)
+		return
+	}
+	var opts shema.ScriptOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		HandlerErr(c, err)
+		return
 	}
-	login := session.Get("login").(string)
-	res, err := s.service.QueryFromFile(c.Request.Context(), file, login)
+	login := currentUser(c).Login
+	res, err := s.service.QueryFromFile(c.Request.Context(), file, login, connectionID(c, session), opts)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
 
-	c.HTML(http.StatusOK, "result.html", gin.H{
-		"data": res,
+	render(c, http.StatusOK, "result.html", gin.H{
+		"script": res,
 	})
 }
 
 func (s *Handler) GetHistory(c *gin.Context) {
 	session := sessions.Default(c)
-	login := session.Get("login").(string)
-	res, err := s.service.GetHistory(c.Request.Context(), login)
+	login := currentUser(c).Login
+
+	var filter shema.HistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	// форма выбирает подключение одним полем "тип:имя базы"
+	if db := c.Query("db"); db != "" {
+		filter.TypeDB, filter.DBName, _ = strings.Cut(db, ":")
+	}
+	ctx := c.Request.Context()
+	page, err := s.service.GetHistory(ctx, login, filter)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
-
-	data := make([][]string, len(res))
-	for i, row := range res {
-		data[i] = make([]string, len(row))
-		for j, col := range row {
-			data[i][j] = fmt.Sprint(col)
-		}
+	databases, err := s.service.HistoryDatabases(ctx, login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
 	}
 
-	c.HTML(http.StatusOK, "history.html", gin.H{
-		"data": data,
+	links := pageLinks(page.Offset, page.Limit, page.HasMore, func(offset int) string {
+		f := filter
+		f.Offset = offset
+		return historyURL(f)
+	})
+
+	render(c, http.StatusOK, "history.html", gin.H{
+		"history":   page,
+		"filter":    filter,
+		"databases": databases,
+		"statuses":  shema.HistoryStatuses,
+		"links":     links,
+		"current":   connectionID(c, session),
 	})
 }
 
+// pageLinks строит ссылки на соседние страницы списка с теми же фильтрами
+func pageLinks(offset, limit int, hasMore bool, link func(offset int) string) gin.H {
+	links := gin.H{}
+	if offset > 0 {
+		links["Prev"] = link(max(offset-limit, 0))
+	}
+	if hasMore {
+		links["Next"] = link(offset + limit)
+	}
+	return links
+}
+
+func historyURL(f shema.HistoryFilter) string {
+	v := url.Values{}
+	for key, value := range map[string]string{"search": f.Search, "from": f.From, "to": f.To, "typeDB": f.TypeDB, "dbName": f.DBName, "status": f.Status} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.Offset > 0 {
+		v.Set("offset", strconv.Itoa(f.Offset))
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return "/history?" + v.Encode()
+}
+
 func (s *Handler) SwitchDatabase(c *gin.Context) {
 	session := sessions.Default(c)
-	login := session.Get("login").(string)
-	db := session.Get("database").(string)
-	err := s.service.Switch(login, db)
+	login := currentUser(c).Login
+	connID := connectionID(c, session)
+	err := s.service.Switch(login, connID)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
-	session.Delete("database")
-	session.Save()
+	if session.Get("connection") == connID {
+		session.Delete("database")
+		session.Delete("connection")
+		session.Save()
+	}
 	c.Redirect(http.StatusMovedPermanently, "/")
 
 }
 
-func (s *Handler) CreateDatabase(c *gin.Context) {
+func (s *Handler) CloseConnection(c *gin.Context) {
 	session := sessions.Default(c)
-	user := session.Get("login").(string)
+	login := currentUser(c).Login
+	connID := c.PostForm("connection")
+	err := s.service.CloseConnection(login, connID)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	if session.Get("connection") == connID {
+		session.Delete("database")
+		session.Delete("connection")
+		session.Save()
+	}
+	c.Redirect(http.StatusMovedPermanently, "/smartTable")
+}
+
+func (s *Handler) RunningQueries(c *gin.Context) {
+	login := currentUser(c).Login
+
+	render(c, http.StatusOK, "running.html", gin.H{
+		"queries": s.service.RunningQueries(login),
+	})
+}
+
+func (s *Handler) CancelQuery(c *gin.Context) {
+	login := currentUser(c).Login
+	err := s.service.CancelQuery(login, c.PostForm("query"))
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		HandlerErr(c, err)
+		return
+	}
+	// запрос мог завершиться, пока пользователь нажимал кнопку
+	c.Redirect(http.StatusMovedPermanently, "/queries")
+}
+
+// queryParams собирает параметры из параллельных полей pname, ptype и pvalue, в pnull - имена параметров со значением NULL
+func queryParams(c *gin.Context) []shema.QueryParam {
+	names, types, values := c.PostFormArray("pname"), c.PostFormArray("ptype"), c.PostFormArray("pvalue")
+	nulls := make(map[string]bool)
+	for _, name := range c.PostFormArray("pnull") {
+		nulls[name] = true
+	}
+	res := make([]shema.QueryParam, 0, len(names))
+	for i, name := range names {
+		p := shema.QueryParam{Name: name, Null: nulls[name]}
+		if i < len(types) {
+			p.Type = types[i]
+		}
+		if i < len(values) {
+			p.Value = values[i]
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// connectionID берет подключение из формы или query-параметра, иначе - выбранное в сессии
+func connectionID(c *gin.Context, session sessions.Session) string {
+	if id := c.PostForm("connection"); id != "" {
+		return id
+	}
+	if id := c.Query("connection"); id != "" {
+		return id
+	}
+	id, _ := session.Get("connection").(string)
+	return id
+}
+
+func (s *Handler) CreateDatabase(c *gin.Context) {
+	user := currentUser(c).Login
 	login := c.PostForm("login")
 	password := c.PostForm("password")
 	dbName := c.PostForm("databaseName")
@@ -341,7 +542,7 @@ func (s *Handler) CreateDatabase(c *gin.Context) {
 	if err != nil {
 		log.Fatalf("Ошибка при вызове CreateDB: %v", err)
 	}
-	c.HTML(http.StatusOK, "connections.html", gin.H{
+	render(c, http.StatusOK, "connections.html", gin.H{
 		"data": response.GetConnectionString(),
 	})
 
@@ -17,11 +17,25 @@ func HandlerErr(c *gin.Context, err error) {
 			c.Redirect(http.StatusMovedPermanently, "/")
 		case errors.Is(err, constants.ErrInvalidData):
 			c.Redirect(http.StatusMovedPermanently, "/registration")
+		case errors.Is(err, constants.ErrForbidden), errors.Is(err, constants.ErrCSRF):
+			c.JSON(http.StatusForbidden, err.Error())
+		case errors.Is(err, constants.ErrReadOnly):
+			errorPage(c, http.StatusForbidden, "Read-only connection", err)
+		case errors.Is(err, constants.ErrNotFound):
+			errorPage(c, http.StatusNotFound, "Not found", err)
+		case errors.Is(err, constants.ErrQueryTimeout):
+			errorPage(c, http.StatusGatewayTimeout, "Query timed out", err)
+		case errors.Is(err, constants.ErrQueryCanceled):
+			errorPage(c, http.StatusConflict, "Query canceled", err)
+		case errors.Is(err, constants.ErrNoConnection):
+			errorPage(c, http.StatusConflict, "No connection", err)
+		case errors.Is(err, constants.ErrQueryFailed):
+			errorPage(c, http.StatusUnprocessableEntity, "Query failed", err)
 		case errors.As(err, &UnmarshalTypeError):
-			err := fmt.Sprintf("bad json %s", err)
-			c.JSON(http.StatusBadRequest, err)
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("bad json %s", err))
 		default:
-			c.JSON(http.StatusBadRequest, err)
+			// обернутая ошибка сериализуется в {}, поэтому показываем ее текст
+			errorPage(c, http.StatusBadRequest, "Bad request", err)
 		}
 		return
 
@@ -29,3 +43,48 @@ func HandlerErr(c *gin.Context, err error) {
 	c.Status(http.StatusOK)
 	return
 }
+
+// errorPage показывает страницу с текстом ошибки; коды ответа те же, что у APIErr
+func errorPage(c *gin.Context, code int, title string, err error) {
+	c.HTML(code, "error.html", gin.H{"title": title, "message": err.Error()})
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func APIErr(c *gin.Context, err error) {
+	var UnmarshalTypeError *json.UnmarshalTypeError
+	var SyntaxError *json.SyntaxError
+	status, code := http.StatusBadRequest, "bad_request"
+	switch {
+	case errors.Is(err, constants.ErrUnauthorized):
+		status, code = http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, constants.ErrAlreadyExists):
+		status, code = http.StatusConflict, "already_exists"
+	case errors.Is(err, constants.ErrInvalidData):
+		status, code = http.StatusUnauthorized, "invalid_credentials"
+	case errors.Is(err, constants.ErrNoConnection):
+		status, code = http.StatusConflict, "no_connection"
+	case errors.Is(err, constants.ErrReadOnly):
+		status, code = http.StatusForbidden, "read_only"
+	case errors.Is(err, constants.ErrForbidden):
+		status, code = http.StatusForbidden, "forbidden"
+	case errors.Is(err, constants.ErrCSRF):
+		status, code = http.StatusForbidden, "csrf_failed"
+	case errors.Is(err, constants.ErrNotFound):
+		status, code = http.StatusNotFound, "not_found"
+	case errors.Is(err, constants.ErrQueryTimeout):
+		status, code = http.StatusGatewayTimeout, "query_timeout"
+	case errors.Is(err, constants.ErrQueryCanceled):
+		status, code = http.StatusConflict, "query_canceled"
+	case errors.Is(err, constants.ErrQueryFailed):
+		status, code = http.StatusUnprocessableEntity, "query_failed"
+	case errors.As(err, &UnmarshalTypeError), errors.As(err, &SyntaxError):
+		code = "bad_json"
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": apiError{Code: code, Message: err.Error()},
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"smartTables/internal/constants"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlerErrPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("%w: DELETE statement is not allowed", constants.ErrReadOnly), http.StatusForbidden},
+		{fmt.Errorf("%w: saved query 7", constants.ErrNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: context deadline exceeded", constants.ErrQueryTimeout), http.StatusGatewayTimeout},
+		{fmt.Errorf("%w: context canceled", constants.ErrQueryCanceled), http.StatusConflict},
+		{fmt.Errorf("%w: no such table: t", constants.ErrQueryFailed), http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: no active connection", constants.ErrNoConnection), http.StatusConflict},
+		{errors.New("query is not pageable"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.LoadHTMLGlob("../../templates/html/*")
+		r.GET("/", func(c *gin.Context) { HandlerErr(c, tt.err) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.err.Error()) {
+			t.Fatalf("%v: got %d %q, want %d and the error message", tt.err, w.Code, w.Body, tt.code)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"smartTables/internal/domains"
+	"smartTables/internal/shema"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// historyService запоминает фильтр истории и отдает одну и ту же базу двух типов
+type historyService struct {
+	domains.Service
+	filter shema.HistoryFilter
+}
+
+func (s *historyService) GetHistory(ctx context.Context, user string, filter shema.HistoryFilter) (*shema.HistoryPage, error) {
+	s.filter = filter
+	return &shema.HistoryPage{Entries: []shema.HistoryEntry{}, Limit: 50}, nil
+}
+
+func (s *historyService) HistoryDatabases(ctx context.Context, user string) ([]shema.HistoryDatabase, error) {
+	return []shema.HistoryDatabase{{TypeDB: "mysql", DBName: "shop"}, {TypeDB: "postgres", DBName: "shop"}}, nil
+}
+
+func TestHistoryFiltersByConnectionType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := &historyService{}
+	h := &Handler{service: svc}
+	r := gin.New()
+	r.LoadHTMLGlob("../../templates/html/*")
+	r.Use(sessions.Sessions("token", cookie.NewStore([]byte("0123456789abcdef0123456789abcdef"))))
+	r.GET("/history", func(c *gin.Context) { c.Set(principalKey, Principal{Login: "alice"}) }, h.GetHistory)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history?db=postgres:shop", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200; body %s", w.Code, w.Body)
+	}
+	if svc.filter.TypeDB != "postgres" || svc.filter.DBName != "shop" {
+		t.Fatalf("filter: got %q %q, want postgres shop", svc.filter.TypeDB, svc.filter.DBName)
+	}
+	if !strings.Contains(w.Body.String(), `value="postgres:shop" selected`) || strings.Contains(w.Body.String(), `value="mysql:shop" selected`) {
+		t.Fatalf("only postgres shop must be selected; body %s", w.Body)
+	}
+}
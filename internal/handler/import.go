@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/shema"
+	"strconv"
+)
+
+func (s *Handler) ImportPreview(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	file, err := c.FormFile("importFile")
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	var opts shema.ImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	opts.ConnID = connectionID(c, session)
+	preview, err := s.service.PreviewImport(c.Request.Context(), login, file, opts)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "import.html", gin.H{
+		"preview": preview,
+	})
+}
+
+func (s *Handler) ImportRun(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	req := shema.ImportRequest{
+		Token:  c.PostForm("token"),
+		Table:  c.PostForm("table"),
+		Append: c.PostForm("append") == "true",
+		ConnID: connectionID(c, session),
+	}
+	// name и type идут по одному на каждую колонку файла, пустое имя - колонку пропустить
+	names, types := c.PostFormArray("name"), c.PostFormArray("type")
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		col := shema.ImportColumn{Source: i, Name: name}
+		if i < len(types) {
+			col.Type = types[i]
+		}
+		req.Columns = append(req.Columns, col)
+	}
+	if timeout := c.PostForm("timeout"); timeout != "" {
+		var err error
+		if req.Timeout, err = strconv.Atoi(timeout); err != nil {
+			HandlerErr(c, err)
+			return
+		}
+	}
+
+	res, err := s.service.Import(c.Request.Context(), login, req)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "import.html", gin.H{
+		"result": res,
+	})
+}
+
+func (s *Handler) APIImportPreview(c *gin.Context) {
+	login := currentUser(c).Login
+
+	file, err := c.FormFile("importFile")
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	var opts shema.ImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		APIErr(c, err)
+		return
+	}
+	opts.ConnID = c.PostForm("connectionId")
+	preview, err := s.service.PreviewImport(c.Request.Context(), login, file, opts)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func (s *Handler) APIImport(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var req shema.ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	res, err := s.service.Import(c.Request.Context(), login, req)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
@@ -1,21 +1,107 @@
 package handler
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"smartTables/internal/shema"
+)
 
 func Route(c *gin.Engine, h *Handler) {
-	c.GET("/smartTable", h.GetHome)
-	c.POST("/smartTable", h.PostHome)
-	c.GET("/result", h.GetResult)
-	c.GET("/", h.ConnectionGet)
-	c.POST("/", h.ConnectionPost)
+	editor := h.requireRole(shema.RoleEditor)
+
 	c.GET("/registration", h.Registration)
 	c.POST("/registration", h.RegistrationPost)
 	c.GET("/login", h.Login)
 	c.POST("/login", h.LoginPost)
-	c.GET("/tables", h.ShowTables)
-	c.POST("/logout", h.Logout)
-	c.POST("/upload", h.GetFile)
-	c.GET("/history", h.GetHistory)
-	c.POST("/switch", h.SwitchDatabase)
-	c.POST("/grpc", h.CreateDatabase)
+
+	// все остальное - только для вошедших пользователей
+	auth := c.Group("", authRequired)
+	auth.GET("/smartTable", h.GetHome)
+	auth.POST("/smartTable", h.PostHome)
+	auth.GET("/result", h.GetResult)
+	auth.GET("/", h.ConnectionGet)
+	auth.POST("/", h.ConnectionPost)
+	auth.GET("/tables", h.ShowTables)
+	auth.GET("/tables/data", h.TableData)
+	auth.POST("/tables/edit", editor, h.TableEdit)
+	auth.POST("/logout", h.Logout)
+	auth.POST("/upload", h.GetFile)
+	auth.GET("/history", h.GetHistory)
+	auth.POST("/switch", h.SwitchDatabase)
+	auth.POST("/connections/close", h.CloseConnection)
+	auth.POST("/export", h.Export)
+	auth.POST("/import", editor, h.ImportPreview)
+	auth.POST("/import/run", editor, h.ImportRun)
+	auth.GET("/queries", h.RunningQueries)
+	auth.POST("/queries/cancel", h.CancelQuery)
+	auth.GET("/sessions", h.Sessions)
+	auth.POST("/sessions/revoke", h.RevokeSession)
+	auth.GET("/saved", h.SavedQueries)
+	auth.POST("/saved", h.StoreSavedQuery)
+	auth.GET("/saved/new", h.NewSavedQuery)
+	auth.POST("/saved/new", h.NewSavedQuery)
+	auth.GET("/saved/:id", h.SavedQuery)
+	auth.POST("/saved/:id/delete", h.DeleteSavedQuery)
+	auth.POST("/saved/:id/run", h.RunSavedQuery)
+	auth.POST("/grpc", editor, h.CreateDatabase)
+
+	admin := auth.Group("/admin", h.requireRole(shema.RoleAdmin))
+	admin.GET("/audit", h.AuditLog)
+	admin.GET("/users", h.AdminUsers)
+	admin.POST("/users/role", h.AdminSetRole)
+	admin.POST("/users/sessions", h.AdminRevokeSessions)
+	admin.POST("/grants", h.AdminGrant)
+	admin.POST("/teams", h.AdminCreateTeam)
+	admin.POST("/teams/delete", h.AdminDeleteTeam)
+	admin.POST("/teams/members", h.AdminTeamMember)
+
+	api := c.Group("/api/v1")
+	api.POST("/auth/registration", h.APIRegistration)
+	api.GET("/auth/csrf", h.APICSRF)
+	api.POST("/auth/login", h.APILogin)
+
+	apiAuth := api.Group("", authRequired)
+	apiAuth.POST("/auth/logout", h.APILogout)
+	apiAuth.GET("/connections", h.APIConnections)
+	apiAuth.POST("/connections", editor, h.APIConnect)
+	apiAuth.POST("/connections/saved/:id", h.APIConnectSaved)
+	apiAuth.GET("/connections/open", h.APIOpenConnections)
+	apiAuth.DELETE("/connections/open/:id", h.APICloseConnection)
+	apiAuth.POST("/connections/switch", h.APISwitch)
+	apiAuth.GET("/pools", h.APIPools)
+	apiAuth.GET("/tables", h.APITables)
+	apiAuth.GET("/schema", h.APISchema)
+	apiAuth.POST("/tables/data", h.APITableData)
+	apiAuth.POST("/tables/edit", editor, h.APITableEdit)
+	apiAuth.POST("/query", h.APIQuery)
+	apiAuth.POST("/query/params", h.APIQueryParams)
+	apiAuth.POST("/query/file", h.APIQueryFile)
+	apiAuth.POST("/query/export", h.APIExport)
+	apiAuth.POST("/import", editor, h.APIImportPreview)
+	apiAuth.POST("/import/run", editor, h.APIImport)
+	apiAuth.GET("/history", h.APIHistory)
+	apiAuth.GET("/saved", h.APISavedQueries)
+	apiAuth.POST("/saved", h.APIStoreSavedQuery)
+	apiAuth.GET("/saved/:id", h.APISavedQuery)
+	apiAuth.PUT("/saved/:id", h.APIStoreSavedQuery)
+	apiAuth.DELETE("/saved/:id", h.APIDeleteSavedQuery)
+	apiAuth.POST("/saved/:id/run", h.APIRunSavedQuery)
+	apiAuth.GET("/queries", h.APIRunningQueries)
+	apiAuth.DELETE("/queries/:id", h.APICancelQuery)
+	apiAuth.GET("/teams", h.APIUserTeams)
+	apiAuth.GET("/sessions", h.APISessions)
+	apiAuth.DELETE("/sessions/:id", h.APIRevokeSession)
+
+	apiAdmin := apiAuth.Group("/admin", h.requireRole(shema.RoleAdmin))
+	apiAdmin.GET("/audit", h.APIAuditLog)
+	apiAdmin.GET("/users", h.APIUsers)
+	apiAdmin.PUT("/users/:login/role", h.APISetRole)
+	apiAdmin.DELETE("/users/:login/sessions", h.APIRevokeUserSessions)
+	apiAdmin.GET("/grants", h.APIGrants)
+	apiAdmin.POST("/grants", h.APIGrant)
+	apiAdmin.DELETE("/grants/:id/:login", h.APIRevoke)
+	apiAdmin.GET("/teams", h.APITeams)
+	apiAdmin.POST("/teams", h.APICreateTeam)
+	apiAdmin.DELETE("/teams/:id", h.APIDeleteTeam)
+	apiAdmin.POST("/teams/:id/members", h.APIAddTeamMember)
+	apiAdmin.DELETE("/teams/:id/members/:login", h.APIRemoveTeamMember)
 }
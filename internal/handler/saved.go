@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/constants"
+	"smartTables/internal/importer"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+)
+
+// splitList разбирает список через запятую из поля формы
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// savedRunRequest - QueryRequest без текста запроса: он берется из библиотеки
+type savedRunRequest struct {
+	ConnID  string             `json:"connectionId" form:"connection"`
+	QueryID string             `json:"queryId" form:"queryId"`
+	Timeout int                `json:"timeout" form:"timeout"`
+	Offset  int                `json:"offset" form:"offset"`
+	Limit   int                `json:"limit" form:"limit"`
+	Params  []shema.QueryParam `json:"params" form:"-"`
+}
+
+func (r savedRunRequest) request() shema.QueryRequest {
+	return shema.QueryRequest{ConnID: r.ConnID, QueryID: r.QueryID, Timeout: r.Timeout, Offset: r.Offset, Limit: r.Limit, Params: r.Params}
+}
+
+func savedQueryID(c *gin.Context) (int64, error) {
+	return strconv.ParseInt(c.Param("id"), 10, 64)
+}
+
+func (s *Handler) SavedQueries(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var filter shema.SavedQueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	queries, err := s.service.ListSavedQueries(c.Request.Context(), login, filter)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "saved.html", gin.H{
+		"queries": queries,
+		"filter":  filter,
+		"login":   login,
+	})
+}
+
+// NewSavedQuery открывает пустой редактор; POST из основного редактора подставляет текст запроса
+func (s *Handler) NewSavedQuery(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	q := shema.SavedQuery{Owner: login, Query: c.PostForm("query"), Params: queryParams(c)}
+	s.renderSavedQuery(c, http.StatusOK, login, connectionID(c, session), q, "")
+}
+
+func (s *Handler) SavedQuery(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	q, err := s.service.GetSavedQuery(c.Request.Context(), login, id)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	s.renderSavedQuery(c, http.StatusOK, login, connectionID(c, session), q, "")
+}
+
+// renderSavedQuery показывает редактор сохраненного запроса и форму его запуска
+func (s *Handler) renderSavedQuery(c *gin.Context, status int, login, connID string, q shema.SavedQuery, message string) {
+	render(c, status, "savedQuery.html", gin.H{
+		"query":       q,
+		"owner":       q.ID == 0 || q.Owner == login,
+		"types":       importer.Types,
+		"connections": s.service.ListConnections(login),
+		"current":     connID,
+		"message":     message,
+	})
+}
+
+func (s *Handler) StoreSavedQuery(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	q := shema.SavedQuery{
+		Name:        c.PostForm("name"),
+		Description: c.PostForm("description"),
+		Query:       c.PostForm("query"),
+		TypeDB:      c.PostForm("typeDB"),
+		Tags:        splitList(c.PostForm("tags")),
+		SharedWith:  splitList(c.PostForm("sharedWith")),
+		Params:      queryParams(c),
+	}
+	if id := c.PostForm("id"); id != "" {
+		var err error
+		if q.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+			HandlerErr(c, err)
+			return
+		}
+	}
+	id, err := s.service.StoreSavedQuery(c.Request.Context(), login, q)
+	if errors.Is(err, constants.ErrAlreadyExists) {
+		// повторяющееся имя - показываем форму снова, не теряя введенное
+		q.Owner = login
+		s.renderSavedQuery(c, http.StatusConflict, login, connectionID(c, session), q, "A saved query with this name already exists")
+		return
+	}
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/saved/"+strconv.FormatInt(id, 10))
+}
+
+func (s *Handler) DeleteSavedQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	if err := s.service.DeleteSavedQuery(c.Request.Context(), login, id); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/saved")
+}
+
+func (s *Handler) RunSavedQuery(c *gin.Context) {
+	ctx := c.Request.Context()
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	var run savedRunRequest
+	if err := c.ShouldBind(&run); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	run.ConnID = connectionID(c, session)
+	run.Params = queryParams(c)
+
+	res, req, err := s.service.RunSavedQuery(ctx, login, id, run.request())
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	if res.Columns == nil {
+		render(c, http.StatusOK, "smartTables.html", gin.H{
+			"message":     execMessage(res),
+			"connections": s.service.ListConnections(login),
+			"current":     req.ConnID,
+		})
+		return
+	}
+	render(c, http.StatusOK, "result.html", gin.H{
+		"data": res,
+		"page": newResultPage(req, res),
+	})
+}
+
+func (s *Handler) APISavedQueries(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var filter shema.SavedQueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		APIErr(c, err)
+		return
+	}
+	queries, err := s.service.ListSavedQueries(c.Request.Context(), login, filter)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queries": queries})
+}
+
+func (s *Handler) APISavedQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	q, err := s.service.GetSavedQuery(c.Request.Context(), login, id)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, q)
+}
+
+// APIStoreSavedQuery создает запрос (POST /saved) или меняет его (PUT /saved/:id)
+func (s *Handler) APIStoreSavedQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var q shema.SavedQuery
+	if err := c.ShouldBindJSON(&q); err != nil {
+		APIErr(c, err)
+		return
+	}
+	q.ID = 0
+	status := http.StatusCreated
+	if c.Param("id") != "" {
+		var err error
+		if q.ID, err = savedQueryID(c); err != nil {
+			APIErr(c, err)
+			return
+		}
+		status = http.StatusOK
+	}
+	id, err := s.service.StoreSavedQuery(c.Request.Context(), login, q)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	saved, err := s.service.GetSavedQuery(c.Request.Context(), login, id)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(status, saved)
+}
+
+func (s *Handler) APIDeleteSavedQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	if err := s.service.DeleteSavedQuery(c.Request.Context(), login, id); err != nil {
+		APIErr(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APIRunSavedQuery(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := savedQueryID(c)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	var run savedRunRequest
+	if err := c.ShouldBindJSON(&run); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	res, _, err := s.service.RunSavedQuery(c.Request.Context(), login, id, run.request())
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPIQueryResponse(res))
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"smartTables/config"
+	"smartTables/internal/domains"
+	"smartTables/internal/sessionstore"
+	"strings"
+)
+
+const (
+	sessionStoreCookie   = "cookie"
+	sessionStorePostgres = "postgres"
+)
+
+// newSessionStore создает хранилище сессий по конфигу
+func newSessionStore(service domains.Service, cnf config.Config) (sessions.Store, error) {
+	pairs, err := sessionstore.KeyPairs(cnf.SessionKeys)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		log.Println("sessionKeys are not configured: a random key is used and sessions will not survive a restart")
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, key)
+	}
+
+	switch cnf.SessionStore {
+	case "", sessionStoreCookie:
+		return cookie.NewStore(pairs...), nil
+	case sessionStorePostgres:
+		return sessionstore.NewStore(service, pairs...), nil
+	}
+	return nil, fmt.Errorf("unknown session store %q", cnf.SessionStore)
+}
+
+// cookieOptions собирает параметры cookie сессии из конфига
+func cookieOptions(cnf config.Config) (sessions.Options, error) {
+	opts := sessions.Options{Path: "/", MaxAge: 86400 * 30, HttpOnly: true, Secure: cnf.CookieSecure}
+	switch strings.ToLower(cnf.CookieSameSite) {
+	case "", "lax":
+		opts.SameSite = http.SameSiteLaxMode
+	case "strict":
+		opts.SameSite = http.SameSiteStrictMode
+	case "none":
+		// браузеры принимают SameSite=None только вместе с Secure
+		if !cnf.CookieSecure {
+			return opts, fmt.Errorf("cookieSameSite none requires cookieSecure")
+		}
+		opts.SameSite = http.SameSiteNoneMode
+	default:
+		return opts, fmt.Errorf("unknown cookieSameSite %q", cnf.CookieSameSite)
+	}
+	return opts, nil
+}
+
+// loginCookie - параметры cookie после входа: сессия живет час
+func (s *Handler) loginCookie() sessions.Options {
+	opts := s.cookie
+	opts.MaxAge = 60 * 60
+	return opts
+}
+
+// startSession отмечает вход в сессии. ID сессии и CSRF-токен выдаются заново, чтобы сессия,
+// подсунутая до входа, не стала сессией пользователя. Возвращает новый CSRF-токен.
+func (s *Handler) startSession(c *gin.Context, login string) (string, error) {
+	token, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	session := sessions.Default(c)
+	if s.config.SessionStore == sessionStorePostgres {
+		session.Set(sessionstore.RenewKey, true)
+	}
+	session.Set(csrfKey, token)
+	session.Set("authenticated", true)
+	session.Set("login", login)
+	session.Options(s.loginCookie())
+	return token, session.Save()
+}
+
+func (s *Handler) Sessions(c *gin.Context) {
+	session := sessions.Default(c)
+	login := currentUser(c).Login
+
+	list, err := s.service.ListSessions(c.Request.Context(), login)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+
+	render(c, http.StatusOK, "sessions.html", gin.H{
+		"sessions": list,
+		"current":  sessionstore.ID(session.ID()),
+		"enabled":  s.config.SessionStore == sessionStorePostgres,
+	})
+}
+
+func (s *Handler) RevokeSession(c *gin.Context) {
+	login := currentUser(c).Login
+
+	if err := s.service.RevokeSession(c.Request.Context(), login, c.PostForm("id")); err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/sessions")
+}
+
+func (s *Handler) AdminRevokeSessions(c *gin.Context) {
+	login := currentUser(c).Login
+
+	_, err := s.service.RevokeUserSessions(c.Request.Context(), login, c.PostForm("login"))
+	adminRedirect(c, err)
+}
+
+func (s *Handler) APISessions(c *gin.Context) {
+	login := currentUser(c).Login
+
+	list, err := s.service.ListSessions(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": list, "current": sessionstore.ID(sessions.Default(c).ID())})
+}
+
+func (s *Handler) APIRevokeSession(c *gin.Context) {
+	login := currentUser(c).Login
+
+	if err := s.service.RevokeSession(c.Request.Context(), login, c.Param("id")); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APIRevokeUserSessions(c *gin.Context) {
+	login := currentUser(c).Login
+
+	n, err := s.service.RevokeUserSessions(c.Request.Context(), login, c.Param("login"))
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": n})
+}
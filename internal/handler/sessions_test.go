@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"smartTables/internal/domains"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// loginService принимает любой пароль
+type loginService struct {
+	domains.Service
+}
+
+func (loginService) Login(ctx context.Context, login, password string) error {
+	return nil
+}
+
+func TestAPILoginRenewsCSRFToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: loginService{}}
+	r := gin.New()
+	r.Use(sessions.Sessions("token", cookie.NewStore([]byte("0123456789abcdef0123456789abcdef"))), csrfProtect)
+	r.GET("/api/v1/auth/csrf", h.APICSRF)
+	r.POST("/api/v1/auth/login", h.APILogin)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil))
+	var before struct {
+		CSRFToken string `json:"csrfToken"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &before); err != nil || before.CSRFToken == "" {
+		t.Fatalf("csrf: got %s, %v", w.Body, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"login":"alice","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(csrfHeader, before.CSRFToken)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var after struct {
+		Login     string `json:"login"`
+		CSRFToken string `json:"csrfToken"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &after); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s, %v", w.Code, w.Body, err)
+	}
+	if after.CSRFToken == "" || after.CSRFToken == before.CSRFToken {
+		t.Fatalf("login: got csrf token %q, want a new one instead of %q", after.CSRFToken, before.CSRFToken)
+	}
+}
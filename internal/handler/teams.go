@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"smartTables/internal/shema"
+	"strconv"
+)
+
+func (s *Handler) AdminCreateTeam(c *gin.Context) {
+	login := currentUser(c).Login
+
+	_, err := s.service.CreateTeam(c.Request.Context(), login, c.PostForm("name"))
+	adminRedirect(c, err)
+}
+
+func (s *Handler) AdminDeleteTeam(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.PostForm("teamId"), 10, 64)
+	if err == nil {
+		err = s.service.DeleteTeam(c.Request.Context(), login, id)
+	}
+	adminRedirect(c, err)
+}
+
+func (s *Handler) AdminTeamMember(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var m shema.TeamMember
+	if err := c.ShouldBind(&m); err != nil {
+		adminRedirect(c, err)
+		return
+	}
+	var err error
+	if c.PostForm("remove") == "true" {
+		err = s.service.RemoveTeamMember(c.Request.Context(), login, m)
+	} else {
+		err = s.service.AddTeamMember(c.Request.Context(), login, m)
+	}
+	adminRedirect(c, err)
+}
+
+// APIUserTeams возвращает команды текущего пользователя
+func (s *Handler) APIUserTeams(c *gin.Context) {
+	login := currentUser(c).Login
+
+	teams, err := s.service.UserTeams(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (s *Handler) APITeams(c *gin.Context) {
+	login := currentUser(c).Login
+
+	teams, err := s.service.ListTeams(c.Request.Context(), login)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (s *Handler) APICreateTeam(c *gin.Context) {
+	login := currentUser(c).Login
+
+	var t shema.Team
+	if err := c.ShouldBindJSON(&t); err != nil {
+		APIErr(c, err)
+		return
+	}
+	id, err := s.service.CreateTeam(c.Request.Context(), login, t.Name)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, shema.Team{ID: id, Name: t.Name, Members: []string{}})
+}
+
+func (s *Handler) APIDeleteTeam(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	if err := s.service.DeleteTeam(c.Request.Context(), login, id); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Handler) APIAddTeamMember(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	var req struct {
+		Login string `json:"login" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIErr(c, err)
+		return
+	}
+	m := shema.TeamMember{TeamID: id, Login: req.Login}
+	if err := s.service.AddTeamMember(c.Request.Context(), login, m); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, m)
+}
+
+func (s *Handler) APIRemoveTeamMember(c *gin.Context) {
+	login := currentUser(c).Login
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		APIErr(c, err)
+		return
+	}
+	m := shema.TeamMember{TeamID: id, Login: c.Param("login")}
+	if err := s.service.RemoveTeamMember(c.Request.Context(), login, m); err != nil {
+		APIErr(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"smartTables/internal/shema"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CSV  = "csv"
+	TSV  = "tsv"
+	XLSX = "xlsx"
+)
+
+// Source отдает строки файла по одной, в конце возвращает io.EOF
+type Source interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+// Normalize определяет формат по расширению, если он не задан, и проверяет разделитель
+func Normalize(filename string, opts shema.ImportOptions) (shema.ImportOptions, error) {
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".tsv", ".tab":
+			opts.Format = TSV
+		case ".xlsx":
+			opts.Format = XLSX
+		default:
+			opts.Format = CSV
+		}
+	}
+	switch opts.Format {
+	case CSV:
+		if opts.Delimiter == "" {
+			opts.Delimiter = ","
+		}
+	case TSV:
+		if opts.Delimiter == "" {
+			opts.Delimiter = "\t"
+		}
+	case XLSX:
+		return opts, nil
+	default:
+		return opts, fmt.Errorf("unknown import format %q", opts.Format)
+	}
+	if opts.Delimiter == `\t` || opts.Delimiter == "tab" {
+		opts.Delimiter = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(opts.Delimiter)
+	if size != len(opts.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return opts, fmt.Errorf("bad delimiter %q", opts.Delimiter)
+	}
+	return opts, nil
+}
+
+// Open открывает файл, прошедший Normalize
+func Open(path string, opts shema.ImportOptions) (Source, error) {
+	if opts.Format == XLSX {
+		return openXLSX(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.Comma, _ = utf8.DecodeRuneInString(opts.Delimiter)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+	return &csvSource{f: f, r: r}, nil
+}
+
+type csvSource struct {
+	f     *os.File
+	r     *csv.Reader
+	first bool
+}
+
+func (c *csvSource) Next() ([]string, error) {
+	rec, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if !c.first {
+		// Excel сохраняет CSV в UTF-8 с BOM
+		c.first = true
+		if len(rec) > 0 {
+			rec[0] = strings.TrimPrefix(rec[0], "\ufeff")
+		}
+	}
+	return rec, nil
+}
+
+func (c *csvSource) Close() error {
+	return c.f.Close()
+}
+
+// ReadHeader читает первую строку как имена колонок или, при NoHeader, называет колонки по номерам
+// и возвращает первую строку данных отдельно
+func ReadHeader(src Source, noHeader bool) ([]string, []string, error) {
+	row, err := src.Next()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("file is empty")
+		}
+		return nil, nil, err
+	}
+	row = append([]string(nil), row...)
+	header := make([]string, len(row))
+	for i, name := range row {
+		name = strings.TrimSpace(name)
+		if noHeader || name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		header[i] = name
+	}
+	if noHeader {
+		return header, row, nil
+	}
+	return header, nil, nil
+}
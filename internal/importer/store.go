@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"os"
+	"smartTables/internal/constants"
+	"smartTables/internal/randid"
+	"smartTables/internal/shema"
+	"sync"
+	"time"
+)
+
+type upload struct {
+	user    string
+	path    string
+	opts    shema.ImportOptions
+	created time.Time
+}
+
+// Store помнит загруженные файлы между предпросмотром и самим импортом
+type Store struct {
+	mu      sync.Mutex
+	uploads map[string]upload
+	ttl     time.Duration
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{uploads: make(map[string]upload), ttl: ttl}
+}
+
+// Put запоминает файл и возвращает токен; заодно удаляет файлы, которые так и не импортировали
+func (s *Store) Put(user, path string, opts shema.ImportOptions) string {
+	token := randid.Hex(16)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for t, u := range s.uploads {
+		if now.Sub(u.created) > s.ttl {
+			os.Remove(u.path)
+			delete(s.uploads, t)
+		}
+	}
+	s.uploads[token] = upload{user: user, path: path, opts: opts, created: now}
+	return token
+}
+
+func (s *Store) Get(user, token string) (string, shema.ImportOptions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[token]
+	if !ok || u.user != user {
+		return "", shema.ImportOptions{}, constants.ErrNotFound
+	}
+	return u.path, u.opts, nil
+}
+
+// Remove забывает токен и удаляет файл
+func (s *Store) Remove(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.uploads[token]; ok {
+		os.Remove(u.path)
+		delete(s.uploads, token)
+	}
+}
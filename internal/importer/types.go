@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"fmt"
+	"smartTables/internal/dialect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Integer   = "integer"
+	Float     = "float"
+	Boolean   = "boolean"
+	Date      = "date"
+	Timestamp = "timestamp"
+	Text      = "text"
+)
+
+// Types - типы колонок, которые можно выбрать при создании таблицы, от самого строгого
+var Types = []string{Integer, Float, Boolean, Date, Timestamp, Text}
+
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// Infer подбирает для каждой колонки самый строгий тип, которому соответствуют все непустые значения
+func Infer(rows [][]string, columns int) []string {
+	res := make([]string, columns)
+	for col := 0; col < columns; col++ {
+		fits := map[string]bool{Integer: true, Float: true, Boolean: true, Date: true, Timestamp: true}
+		seen := false
+		for _, row := range rows {
+			if col >= len(row) || row[col] == "" {
+				continue
+			}
+			seen = true
+			v := row[col]
+			if fits[Integer] && !isInteger(v) {
+				fits[Integer] = false
+			}
+			if fits[Float] && !isFloat(v) {
+				fits[Float] = false
+			}
+			if fits[Boolean] && !isBoolean(v) {
+				fits[Boolean] = false
+			}
+			if fits[Date] && !isDate(v) {
+				fits[Date] = false
+			}
+			if fits[Timestamp] && !isTimestamp(v) {
+				fits[Timestamp] = false
+			}
+		}
+		res[col] = Text
+		if !seen {
+			continue
+		}
+		for _, t := range Types[:len(Types)-1] {
+			if fits[t] {
+				res[col] = t
+				break
+			}
+		}
+	}
+	return res
+}
+
+// leadingZero: значения вроде 007 (индексы, коды) сохраняем как текст, чтобы не потерять нули
+func leadingZero(v string) bool {
+	digits := strings.TrimPrefix(strings.TrimPrefix(v, "-"), "+")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+}
+
+func isInteger(v string) bool {
+	if leadingZero(v) {
+		return false
+	}
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+func isFloat(v string) bool {
+	if leadingZero(v) {
+		return false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return false
+	}
+	// NaN и Inf базы принимают по-разному
+	return f-f == 0
+}
+
+func isBoolean(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false":
+		return true
+	}
+	return false
+}
+
+func isDate(v string) bool {
+	_, err := time.Parse("2006-01-02", v)
+	return err == nil
+}
+
+func isTimestamp(v string) bool {
+	_, ok := parseTimestamp(v)
+	return ok
+}
+
+func parseTimestamp(v string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// SQLType возвращает тип колонки для CREATE TABLE
+func SQLType(t string, d dialect.Dialect) (string, error) {
+	types := map[dialect.Dialect]map[string]string{
+		dialect.Postgres: {Integer: "BIGINT", Float: "DOUBLE PRECISION", Boolean: "BOOLEAN", Date: "DATE", Timestamp: "TIMESTAMP", Text: "TEXT"},
+		dialect.MySQL:    {Integer: "BIGINT", Float: "DOUBLE", Boolean: "BOOLEAN", Date: "DATE", Timestamp: "DATETIME(6)", Text: "TEXT"},
+		dialect.SQLite:   {Integer: "INTEGER", Float: "REAL", Boolean: "BOOLEAN", Date: "DATE", Timestamp: "TIMESTAMP", Text: "TEXT"},
+	}
+	byType, ok := types[d]
+	if !ok {
+		return "", fmt.Errorf("unsupported database type: %s", d)
+	}
+	sqlType, ok := byType[t]
+	if !ok {
+		return "", fmt.Errorf("unknown column type %q", t)
+	}
+	return sqlType, nil
+}
+
+// Value готовит значение для вставки: пустая строка - NULL, bool и время - в виде, понятном базе.
+// Значение, не подходящее под тип, передается как есть, и ошибку вернет база.
+func Value(v, t string, d dialect.Dialect) interface{} {
+	if v == "" {
+		return nil
+	}
+	switch t {
+	case Boolean:
+		if d == dialect.Postgres || !isBoolean(v) {
+			return v
+		}
+		if strings.EqualFold(v, "true") {
+			return int64(1)
+		}
+		return int64(0)
+	case Timestamp:
+		if d != dialect.MySQL {
+			return v
+		}
+		// DATETIME в MySQL не хранит часовой пояс
+		if ts, ok := parseTimestamp(v); ok {
+			return ts.UTC().Format("2006-01-02 15:04:05.999999")
+		}
+	}
+	return v
+}
@@ -0,0 +1,252 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxSource читает первый лист книги потоково; общие строки загружаются целиком
+type xlsxSource struct {
+	zip    *zip.ReadCloser
+	sheet  io.ReadCloser
+	dec    *xml.Decoder
+	shared []string
+}
+
+func openXLSX(name string) (*xlsxSource, error) {
+	z, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxSource{zip: z}
+	if err := x.open(); err != nil {
+		z.Close()
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxSource) open() error {
+	sheetPath, err := x.firstSheet()
+	if err != nil {
+		return err
+	}
+	if err := x.readShared(); err != nil {
+		return err
+	}
+	f := x.file(sheetPath)
+	if f == nil {
+		return errors.New("xlsx: worksheet not found")
+	}
+	x.sheet, err = f.Open()
+	if err != nil {
+		return err
+	}
+	x.dec = xml.NewDecoder(x.sheet)
+	return nil
+}
+
+func (x *xlsxSource) file(name string) *zip.File {
+	for _, f := range x.zip.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// firstSheet находит путь к первому листу через workbook.xml и его связи
+func (x *xlsxSource) firstSheet() (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := x.decodeFile("xl/workbook.xml", &wb); err != nil || len(wb.Sheets) == 0 {
+		return fallback, nil
+	}
+	if err := x.decodeFile("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return fallback, nil
+	}
+	for _, r := range rels.Rels {
+		if r.ID != wb.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/"), nil
+		}
+		return path.Join("xl", r.Target), nil
+	}
+	return fallback, nil
+}
+
+func (x *xlsxSource) decodeFile(name string, v interface{}) error {
+	f := x.file(name)
+	if f == nil {
+		return errors.New("xlsx: missing " + name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return xml.NewDecoder(r).Decode(v)
+}
+
+func (x *xlsxSource) readShared() error {
+	f := x.file("xl/sharedStrings.xml")
+	if f == nil {
+		return nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := xml.NewDecoder(r)
+	var cur strings.Builder
+	inText, phonetic := false, false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = !phonetic
+			case "rPh":
+				phonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				x.shared = append(x.shared, cur.String())
+			case "t":
+				inText = false
+			case "rPh":
+				phonetic = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+func (x *xlsxSource) Next() ([]string, error) {
+	var (
+		row             []string
+		inRow           bool
+		cellType, ref   string
+		value           strings.Builder
+		inValue, inCell bool
+		col             int
+	)
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			if err == io.EOF && inRow {
+				return row, nil
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				inRow, row, col = true, nil, 0
+			case "c":
+				inCell, cellType, ref = true, "", ""
+				value.Reset()
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "t":
+						cellType = a.Value
+					case "r":
+						ref = a.Value
+					}
+				}
+				if i, ok := columnIndex(ref); ok {
+					col = i
+				}
+			case "v", "t":
+				inValue = inCell
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "row":
+				return row, nil
+			case "c":
+				for len(row) < col {
+					row = append(row, "")
+				}
+				row = append(row, x.cellValue(cellType, value.String()))
+				col++
+				inCell = false
+			case "v", "t":
+				inValue = false
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+func (x *xlsxSource) cellValue(cellType, v string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(x.shared) {
+			return ""
+		}
+		return x.shared[i]
+	case "b":
+		if v == "1" {
+			return "true"
+		}
+		return "false"
+	}
+	return v
+}
+
+// columnIndex переводит ссылку вида AB12 в номер колонки с 0
+func columnIndex(ref string) (int, bool) {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+func (x *xlsxSource) Close() error {
+	if x.sheet != nil {
+		x.sheet.Close()
+	}
+	return x.zip.Close()
+}
@@ -0,0 +1,219 @@
+package pool
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxOpenConns    = 10
+	defaultMaxIdleConns    = 2
+	defaultConnMaxIdleTime = 5 * time.Minute
+	defaultIdleTimeout     = 30 * time.Minute
+)
+
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	// IdleTimeout - через сколько без запросов пул закрывается целиком
+	IdleTimeout time.Duration
+}
+
+type entry struct {
+	key      string
+	db       *sql.DB
+	refs     int
+	lastUsed time.Time
+	// inUse - сколько операций сейчас работает с пулом; такой пул не простаивает
+	inUse int
+}
+
+// Manager открывает пулы к пользовательским базам, переиспользует пулы с одинаковым DSN
+// и закрывает их, когда они больше не нужны или долго простаивают.
+type Manager struct {
+	mu      sync.Mutex
+	opts    Options
+	pools   map[string]*entry
+	byDB    map[*sql.DB]*entry
+	onEvict func(db *sql.DB)
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func New(opts Options) *Manager {
+	if opts.MaxOpenConns <= 0 {
+		opts.MaxOpenConns = defaultMaxOpenConns
+	}
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = defaultMaxIdleConns
+	}
+	if opts.ConnMaxIdleTime <= 0 {
+		opts.ConnMaxIdleTime = defaultConnMaxIdleTime
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = defaultIdleTimeout
+	}
+	m := &Manager{
+		opts:  opts,
+		pools: make(map[string]*entry),
+		byDB:  make(map[*sql.DB]*entry),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+// OnEvict задает функцию, которая вызывается после закрытия простаивающего пула
+func (m *Manager) OnEvict(fn func(db *sql.DB)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onEvict = fn
+}
+
+// Open возвращает пул для driver+dsn, открывая его при необходимости.
+// На каждый вызов Open должен приходиться один Release.
+func (m *Manager) Open(driver, dsn string) (*sql.DB, error) {
+	key := driver + "\x00" + dsn
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.pools[key]; ok {
+		e.refs++
+		e.lastUsed = time.Now()
+		return e.db, nil
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(m.opts.MaxOpenConns)
+	db.SetMaxIdleConns(m.opts.MaxIdleConns)
+	db.SetConnMaxIdleTime(m.opts.ConnMaxIdleTime)
+
+	e := &entry{key: key, db: db, refs: 1, lastUsed: time.Now()}
+	m.pools[key] = e
+	m.byDB[db] = e
+	return db, nil
+}
+
+// Touch отмечает, что пул только что использовался
+func (m *Manager) Touch(db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.byDB[db]; ok {
+		e.lastUsed = time.Now()
+	}
+}
+
+// Acquire отмечает начало работы с пулом: пока работа не закончена, пул не закрывается по простою.
+// Возвращаемую функцию нужно вызвать по окончании работы.
+func (m *Manager) Acquire(db *sql.DB) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.byDB[db]
+	if !ok {
+		return func() {}
+	}
+	e.inUse++
+	e.lastUsed = time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			e.inUse--
+			e.lastUsed = time.Now()
+		})
+	}
+}
+
+// Release уменьшает счетчик ссылок и закрывает пул, когда ссылок не осталось
+func (m *Manager) Release(db *sql.DB) error {
+	m.mu.Lock()
+	e, ok := m.byDB[db]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	e.refs--
+	if e.refs > 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	m.remove(e)
+	m.mu.Unlock()
+	return db.Close()
+}
+
+// Len возвращает количество открытых пулов
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pools)
+}
+
+// Close останавливает фоновую очистку и закрывает все пулы
+func (m *Manager) Close() error {
+	close(m.stop)
+	<-m.done
+
+	m.mu.Lock()
+	entries := make([]*entry, 0, len(m.pools))
+	for _, e := range m.pools {
+		entries = append(entries, e)
+		m.remove(e)
+	}
+	m.mu.Unlock()
+
+	var firstErr error
+	for _, e := range entries {
+		if err := e.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *Manager) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.opts.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			m.evictIdle(now)
+		}
+	}
+}
+
+func (m *Manager) evictIdle(now time.Time) {
+	m.mu.Lock()
+	var evicted []*sql.DB
+	for _, e := range m.pools {
+		if e.inUse == 0 && now.Sub(e.lastUsed) >= m.opts.IdleTimeout {
+			evicted = append(evicted, e.db)
+			m.remove(e)
+		}
+	}
+	onEvict := m.onEvict
+	m.mu.Unlock()
+
+	for _, db := range evicted {
+		db.Close()
+		if onEvict != nil {
+			onEvict(db)
+		}
+	}
+}
+
+// remove вызывается под блокировкой
+func (m *Manager) remove(e *entry) {
+	delete(m.pools, e.key)
+	delete(m.byDB, e.db)
+}
@@ -0,0 +1,32 @@
+package pool
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEvictIdleSkipsPoolInUse(t *testing.T) {
+	m := New(Options{IdleTimeout: time.Hour})
+	defer m.Close()
+
+	db, err := m.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := m.Acquire(db)
+
+	m.evictIdle(time.Now().Add(2 * time.Hour))
+	if m.Len() != 1 {
+		t.Fatalf("pool in use was evicted: got %d pools, want 1", m.Len())
+	}
+
+	release()
+	release()
+	m.evictIdle(time.Now().Add(2 * time.Hour))
+	if m.Len() != 0 {
+		t.Fatalf("idle pool was not evicted: got %d pools, want 0", m.Len())
+	}
+}
@@ -0,0 +1,15 @@
+package randid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Hex возвращает n случайных байт в hex. Ошибка crypto/rand означает неисправную систему, поэтому panic
+func Hex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"database/sql"
+	"smartTables/internal/constants"
+	"smartTables/internal/randid"
+	"smartTables/internal/shema"
+	"sync"
+)
+
+// Registry хранит открытые подключения пользователей.
+// Наружу отдаются только копии, поэтому менять состояние можно лишь через методы.
+type Registry struct {
+	mu    sync.RWMutex
+	users map[string][]shema.Connection
+}
+
+func New() *Registry {
+	return &Registry{users: make(map[string][]shema.Connection)}
+}
+
+// Add регистрирует подключение, выдает ему ID и делает активным.
+// Если у пользователя уже есть такое же подключение (тот же пул и имя базы), активируется оно,
+// а второй результат равен false.
+func (r *Registry) Add(user string, c shema.Connection) (shema.Connection, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.users[user] {
+		if existing.Conn == c.Conn && existing.DBName == c.DBName && existing.TypeDB == c.TypeDB && existing.ReadOnly == c.ReadOnly {
+			r.users[user][i].Flag = true
+			return r.users[user][i], false
+		}
+	}
+
+	c.ID = randid.Hex(8)
+	c.Flag = true
+	r.users[user] = append(r.users[user], c)
+	return c, true
+}
+
+func (r *Registry) Get(user, id string) (shema.Connection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := r.index(user, id)
+	if i < 0 {
+		return shema.Connection{}, constants.ErrNoConnection
+	}
+	return r.users[user][i], nil
+}
+
+// Active возвращает последнее из активных подключений пользователя
+func (r *Registry) Active(user string) (shema.Connection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conns := r.users[user]
+	for i := len(conns) - 1; i >= 0; i-- {
+		if conns[i].Flag {
+			return conns[i], nil
+		}
+	}
+	return shema.Connection{}, constants.ErrNoConnection
+}
+
+func (r *Registry) List(user string) []shema.Connection {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]shema.Connection, len(r.users[user]))
+	copy(res, r.users[user])
+	return res
+}
+
+func (r *Registry) Activate(user, id string) error {
+	return r.setFlag(user, id, true)
+}
+
+func (r *Registry) Deactivate(user, id string) error {
+	return r.setFlag(user, id, false)
+}
+
+// DeactivateType снимает флаг со всех подключений пользователя к базе данного типа
+func (r *Registry) DeactivateType(user, typeDB string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conns, ok := r.users[user]
+	if !ok {
+		return constants.ErrNoConnection
+	}
+	for i := range conns {
+		if conns[i].TypeDB == typeDB {
+			conns[i].Flag = false
+		}
+	}
+	return nil
+}
+
+func (r *Registry) DeactivateAll(user string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conns := r.users[user]
+	for i := range conns {
+		conns[i].Flag = false
+	}
+}
+
+// Remove удаляет подключение из реестра и возвращает его, чтобы вызывающий освободил пул
+func (r *Registry) Remove(user, id string) (shema.Connection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(user, id)
+	if i < 0 {
+		return shema.Connection{}, constants.ErrNoConnection
+	}
+	c := r.users[user][i]
+	r.users[user] = append(r.users[user][:i:i], r.users[user][i+1:]...)
+	if len(r.users[user]) == 0 {
+		delete(r.users, user)
+	}
+	return c, nil
+}
+
+// RemoveAll удаляет все подключения пользователя и возвращает их
+func (r *Registry) RemoveAll(user string) []shema.Connection {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conns := r.users[user]
+	delete(r.users, user)
+	return conns
+}
+
+// RemoveConn удаляет у всех пользователей подключения, использующие данный пул
+func (r *Registry) RemoveConn(db *sql.DB) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := 0
+	for user, conns := range r.users {
+		kept := conns[:0]
+		for _, c := range conns {
+			if c.Conn == db {
+				removed++
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) == 0 {
+			delete(r.users, user)
+		} else {
+			r.users[user] = kept
+		}
+	}
+	return removed
+}
+
+func (r *Registry) setFlag(user, id string, flag bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(user, id)
+	if i < 0 {
+		return constants.ErrNoConnection
+	}
+	r.users[user][i].Flag = flag
+	return nil
+}
+
+// index вызывается под блокировкой
+func (r *Registry) index(user, id string) int {
+	for i, c := range r.users[user] {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
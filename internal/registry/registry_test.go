@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"sync"
+	"testing"
+)
+
+func TestRegistryLifecycle(t *testing.T) {
+	r := New()
+	if _, err := r.Active("alice"); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("Active on empty registry: got %v, want ErrNoConnection", err)
+	}
+
+	pg, _ := r.Add("alice", shema.Connection{TypeDB: "postgresql", DBName: "staging"})
+	my, _ := r.Add("alice", shema.Connection{TypeDB: "mysql", DBName: "prod"})
+	if pg.ID == "" || pg.ID == my.ID {
+		t.Fatalf("expected distinct non-empty IDs, got %q and %q", pg.ID, my.ID)
+	}
+
+	active, err := r.Active("alice")
+	if err != nil || active.ID != my.ID {
+		t.Fatalf("Active: got %+v, %v; want %s", active, err, my.ID)
+	}
+
+	if err := r.Deactivate("alice", my.ID); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ = r.Active("alice"); active.ID != pg.ID {
+		t.Fatalf("after Deactivate: got %s, want %s", active.ID, pg.ID)
+	}
+
+	if err := r.DeactivateType("alice", "postgresql"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Active("alice"); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("after DeactivateType: got %v, want ErrNoConnection", err)
+	}
+
+	if err := r.Activate("alice", pg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := r.Remove("alice", pg.ID); err != nil || removed.ID != pg.ID {
+		t.Fatalf("Remove: got %+v, %v", removed, err)
+	}
+	if _, err := r.Get("alice", pg.ID); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("Get after Remove: got %v, want ErrNoConnection", err)
+	}
+	if got := len(r.List("alice")); got != 1 {
+		t.Fatalf("List after Remove: got %d connections, want 1", got)
+	}
+	if got := len(r.RemoveAll("alice")); got != 1 {
+		t.Fatalf("RemoveAll: got %d connections, want 1", got)
+	}
+}
+
+func TestRegistryDeduplicates(t *testing.T) {
+	r := New()
+	db := &sql.DB{}
+	first, added := r.Add("alice", shema.Connection{TypeDB: "postgresql", DBName: "prod", Conn: db})
+	if !added {
+		t.Fatal("first Add reported an existing connection")
+	}
+	_ = r.Deactivate("alice", first.ID)
+
+	second, added := r.Add("alice", shema.Connection{TypeDB: "postgresql", DBName: "prod", Conn: db})
+	if added || second.ID != first.ID || !second.Flag {
+		t.Fatalf("second Add: got %+v, added=%v; want reactivated %s", second, added, first.ID)
+	}
+
+	r.Add("bob", shema.Connection{TypeDB: "postgresql", DBName: "prod", Conn: db})
+	if got := r.RemoveConn(db); got != 2 {
+		t.Fatalf("RemoveConn: removed %d, want 2", got)
+	}
+	if len(r.List("alice")) != 0 || len(r.List("bob")) != 0 {
+		t.Fatal("RemoveConn left connections behind")
+	}
+}
+
+func TestRegistryReturnsCopies(t *testing.T) {
+	r := New()
+	c, _ := r.Add("bob", shema.Connection{TypeDB: "sqlite"})
+
+	list := r.List("bob")
+	list[0].Flag = false
+	if got, _ := r.Get("bob", c.ID); !got.Flag {
+		t.Fatal("mutating List result changed registry state")
+	}
+}
+
+func TestRegistryUnknownUser(t *testing.T) {
+	r := New()
+	if err := r.DeactivateType("nobody", "mysql"); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("DeactivateType: got %v, want ErrNoConnection", err)
+	}
+	if err := r.Activate("nobody", "x"); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("Activate: got %v, want ErrNoConnection", err)
+	}
+	if _, err := r.Remove("nobody", "x"); !errors.Is(err, constants.ErrNoConnection) {
+		t.Fatalf("Remove: got %v, want ErrNoConnection", err)
+	}
+	r.DeactivateAll("nobody")
+}
+
+// Запускать с -race: два "вкладки" одного пользователя работают с реестром одновременно
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := New()
+	const workers = 8
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			user := fmt.Sprintf("user%d", w%2)
+			for i := 0; i < iterations; i++ {
+				c, _ := r.Add(user, shema.Connection{TypeDB: "postgresql", DBName: fmt.Sprint(i)})
+				_, _ = r.Active(user)
+				_ = r.List(user)
+				_ = r.Deactivate(user, c.ID)
+				_ = r.Activate(user, c.ID)
+				_ = r.DeactivateType(user, "mysql")
+				if i%3 == 0 {
+					r.DeactivateAll(user)
+				}
+				_, _ = r.Remove(user, c.ID)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, user := range []string{"user0", "user1"} {
+		if got := len(r.List(user)); got != 0 {
+			t.Fatalf("%s: %d connections left, want 0", user, got)
+		}
+	}
+}
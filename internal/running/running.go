@@ -0,0 +1,122 @@
+package running
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"smartTables/internal/constants"
+	"smartTables/internal/randid"
+	"smartTables/internal/shema"
+	"sort"
+	"sync"
+	"time"
+)
+
+// validID - ID, который клиент может выбрать сам, чтобы отменить запрос, не дожидаясь ответа
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type entry struct {
+	user   string
+	query  shema.RunningQuery
+	cancel context.CancelFunc
+	// mu держится на время отмены, чтобы соединение запроса не вернулось в пул раньше
+	mu   sync.Mutex
+	done bool
+}
+
+// key - ID запроса выбирает клиент, поэтому они уникальны только в пределах пользователя
+type key struct {
+	user string
+	id   string
+}
+
+// Tracker хранит выполняющиеся запросы пользователей
+type Tracker struct {
+	mu      sync.RWMutex
+	queries map[key]*entry
+}
+
+func New() *Tracker {
+	return &Tracker{queries: make(map[key]*entry)}
+}
+
+// Start регистрирует запрос и возвращает его контекст с таймаутом (0 - без ограничения)
+// и функцию, которую нужно вызвать по завершении, до освобождения соединения.
+func (t *Tracker) Start(ctx context.Context, user string, q shema.RunningQuery, timeout time.Duration) (context.Context, func(), error) {
+	if q.ID == "" {
+		q.ID = randid.Hex(8)
+	} else if !validID.MatchString(q.ID) {
+		return nil, nil, fmt.Errorf("bad query id %q", q.ID)
+	}
+	q.Started = time.Now()
+	if timeout > 0 {
+		deadline := q.Started.Add(timeout)
+		q.Deadline = &deadline
+	}
+
+	k := key{user: user, id: q.ID}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.queries[k]; ok {
+		return nil, nil, fmt.Errorf("%w: query %s is already running", constants.ErrAlreadyExists, q.ID)
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	e := &entry{user: user, query: q, cancel: cancel}
+	t.queries[k] = e
+
+	finish := func() {
+		e.mu.Lock()
+		e.done = true
+		e.mu.Unlock()
+		cancel()
+		t.mu.Lock()
+		delete(t.queries, k)
+		t.mu.Unlock()
+	}
+	return runCtx, finish, nil
+}
+
+// List возвращает запросы пользователя в порядке запуска
+func (t *Tracker) List(user string) []shema.RunningQuery {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make([]shema.RunningQuery, 0)
+	for _, e := range t.queries {
+		if e.user == user {
+			res = append(res, e.query)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Started.Before(res[j].Started)
+	})
+	return res
+}
+
+// Cancel отменяет контекст запроса. fn вызывается до отмены, пока запрос гарантированно
+// выполняется на своем соединении - например, чтобы послать pg_cancel_backend.
+func (t *Tracker) Cancel(user, id string, fn func(q shema.RunningQuery)) error {
+	t.mu.RLock()
+	e, ok := t.queries[key{user: user, id: id}]
+	t.mu.RUnlock()
+	if !ok {
+		return constants.ErrNotFound
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.done {
+		return constants.ErrNotFound
+	}
+	if fn != nil {
+		fn(e.query)
+	}
+	e.cancel()
+	return nil
+}
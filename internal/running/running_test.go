@@ -0,0 +1,45 @@
+package running
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+)
+
+func TestSameIDForDifferentUsers(t *testing.T) {
+	tr := New()
+	q := shema.RunningQuery{ID: "q1"}
+
+	aliceCtx, aliceFinish, err := tr.Start(context.Background(), "alice", q, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer aliceFinish()
+	bobCtx, bobFinish, err := tr.Start(context.Background(), "bob", q, 0)
+	if err != nil {
+		t.Fatalf("start with another user's id: got %v, want nil", err)
+	}
+	defer bobFinish()
+
+	if _, _, err := tr.Start(context.Background(), "alice", q, 0); !errors.Is(err, constants.ErrAlreadyExists) {
+		t.Fatalf("start with own running id: got %v, want %v", err, constants.ErrAlreadyExists)
+	}
+
+	if err := tr.Cancel("bob", "q1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if bobCtx.Err() == nil {
+		t.Fatalf("bob's query was not canceled")
+	}
+	if aliceCtx.Err() != nil {
+		t.Fatalf("alice's query was canceled by bob: got %v, want nil", aliceCtx.Err())
+	}
+
+	bobFinish()
+	if got := tr.List("alice"); len(got) != 1 || got[0].ID != "q1" {
+		t.Fatalf("alice's queries after bob finished: got %v, want [q1]", got)
+	}
+}
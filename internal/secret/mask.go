@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const masked = "****"
+
+var (
+	kvPassword    = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+	mysqlPassword = regexp.MustCompile(`^([^:@/]+):([^@]*)@`)
+)
+
+// MaskDSN скрывает пароль в строке подключения Postgres (URL или key=value) или MySQL
+func MaskDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return masked
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), masked)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", masked)
+			u.RawQuery = q.Encode()
+		}
+		res, err := url.PathUnescape(u.String())
+		if err != nil {
+			return u.String()
+		}
+		return res
+	}
+	if kvPassword.MatchString(dsn) {
+		return kvPassword.ReplaceAllString(dsn, "${1}"+masked)
+	}
+	return mysqlPassword.ReplaceAllString(dsn, "${1}:"+masked+"@")
+}
@@ -0,0 +1,153 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const prefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring шифрует строки конвертом: для каждой записи генерируется свой ключ данных,
+// который шифруется мастер-ключом из конфига. В записи хранится ID мастер-ключа,
+// поэтому старые ключи можно держать в конфиге до окончания перешифрования.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring принимает мастер-ключи в base64 (по 32 байта). Пустой набор ключей означает,
+// что строки хранятся как есть.
+func NewKeyring(active string, keys map[string]string) (*Keyring, error) {
+	k := &Keyring{active: active, keys: make(map[string][]byte, len(keys))}
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %s: want 32 bytes, got %d", id, len(key))
+		}
+		k.keys[id] = key
+	}
+	if len(k.keys) == 0 {
+		return k, nil
+	}
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q: %w", active, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// Enabled сообщает, настроен ли хотя бы один мастер-ключ
+func (k *Keyring) Enabled() bool {
+	return len(k.keys) > 0
+}
+
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	if !k.Enabled() {
+		return plaintext, nil
+	}
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dek, []byte(k.active))
+	if err != nil {
+		return "", err
+	}
+	data, err := encrypt(dek, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return prefix + k.active + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(data), nil
+}
+
+// Open расшифровывает запись; строки без префикса считаются записанными до включения шифрования
+func (k *Keyring) Open(sealed string) (string, error) {
+	if !IsSealed(sealed) {
+		return sealed, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	kek, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", parts[0], ErrUnknownKey)
+	}
+	enc := base64.RawStdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	data, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	dek, err := decrypt(kek, wrapped, []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decrypt(dek, data, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation сообщает, что запись нужно перешифровать активным ключом
+func (k *Keyring) NeedsRotation(sealed string) bool {
+	if !k.Enabled() {
+		return false
+	}
+	if !IsSealed(sealed) {
+		return true
+	}
+	return !strings.HasPrefix(sealed, prefix+k.active+":")
+}
+
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strings"
+)
+
+// UserRole возвращает роль пользователя. Логины из config.Admins - всегда администраторы,
+// так назначается первый администратор.
+func (s *Service) UserRole(ctx context.Context, user string) (string, error) {
+	const op = "service.UserRole"
+	if slices.Contains(s.config.Admins, user) {
+		return shema.RoleAdmin, nil
+	}
+	role, err := s.storage.GetUserRole(ctx, user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return "", err
+	}
+	return role, nil
+}
+
+// requireRole возвращает ErrForbidden, если роль пользователя ниже min
+func (s *Service) requireRole(ctx context.Context, user, min string) error {
+	role, err := s.UserRole(ctx, user)
+	if err != nil {
+		return err
+	}
+	if !shema.RoleAllows(role, min) {
+		return fmt.Errorf("%w: %s role required", constants.ErrForbidden, min)
+	}
+	return nil
+}
+
+// IsAdmin сообщает, администратор ли пользователь
+func (s *Service) IsAdmin(ctx context.Context, user string) bool {
+	return s.requireRole(ctx, user, shema.RoleAdmin) == nil
+}
+
+func (s *Service) ListUsers(ctx context.Context, admin string) ([]shema.User, error) {
+	const op = "service.ListUsers"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return nil, err
+	}
+	users, err := s.storage.ListUsers(ctx)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't list users: %w", err)
+	}
+	for i := range users {
+		if slices.Contains(s.config.Admins, users[i].Login) {
+			users[i].Role = shema.RoleAdmin
+		}
+	}
+	return users, nil
+}
+
+// SetUserRole меняет роль пользователя. Открытые им подключения закрываются,
+// чтобы новые права действовали сразу, а не после следующего входа.
+func (s *Service) SetUserRole(ctx context.Context, admin, user, role string) error {
+	const op = "service.SetUserRole"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return err
+	}
+	if !slices.Contains(shema.Roles, role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	if user == admin {
+		return errors.New("you can't change your own role")
+	}
+	if slices.Contains(s.config.Admins, user) {
+		return fmt.Errorf("%s is an administrator by configuration", user)
+	}
+	if err := s.storage.SetUserRole(ctx, user, role); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.release(s.connections.RemoveAll(user)...)
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditRoleChange, Success: true, Detail: user + " -> " + role})
+	return nil
+}
+
+func (s *Service) ListConnectionAccess(ctx context.Context, admin string) ([]shema.ConnectionAccess, error) {
+	const op = "service.ListConnectionAccess"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return nil, err
+	}
+	res, err := s.storage.ListConnectionAccess(ctx)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't list connections: %w", err)
+	}
+	return res, nil
+}
+
+// GrantConnection выдает пользователю сохраненное подключение
+func (s *Service) GrantConnection(ctx context.Context, admin string, g shema.ConnectionGrant) error {
+	const op = "service.GrantConnection"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return err
+	}
+	g.Login = strings.TrimSpace(g.Login)
+	if err := s.storage.GrantConnection(ctx, g); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditGrant, Success: true, ConnectionID: g.ConnectionID, Detail: g.Login})
+	return nil
+}
+
+// RevokeConnection отзывает доступ и закрывает у пользователя уже открытые копии этого подключения
+func (s *Service) RevokeConnection(ctx context.Context, admin string, g shema.ConnectionGrant) error {
+	const op = "service.RevokeConnection"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return err
+	}
+	if err := s.storage.RevokeConnection(ctx, g); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.closeWhere(g.Login, func(c shema.Connection) bool { return c.SavedID == g.ConnectionID })
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditRevoke, Success: true, ConnectionID: g.ConnectionID, Detail: g.Login})
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"smartTables/internal/shema"
+	"strings"
+)
+
+// AuditEvents ищет по журналу аудита; доступно только администраторам
+func (s *Service) AuditEvents(ctx context.Context, user string, filter shema.AuditFilter) (*shema.AuditPage, error) {
+	const op = "service.AuditEvents"
+	if err := s.requireRole(ctx, user, shema.RoleAdmin); err != nil {
+		return nil, err
+	}
+	filter.Actor = strings.TrimSpace(filter.Actor)
+	filter.QueryHash = strings.ToLower(strings.TrimSpace(filter.QueryHash))
+	if err := checkDates(filter.From, filter.To); err != nil {
+		return nil, err
+	}
+	limit, err := listLimit(filter.Offset, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+
+	events, err := s.storage.GetAuditEvents(ctx, filter)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get audit log: %w", err)
+	}
+	page := &shema.AuditPage{Events: events, Offset: filter.Offset, Limit: filter.Limit}
+	if len(events) > filter.Limit {
+		page.Events, page.HasMore = events[:filter.Limit], true
+	}
+	return page, nil
+}
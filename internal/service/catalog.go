@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"smartTables/internal/catalog"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+)
+
+// GetSchema возвращает дерево схем подключения: таблицы, представления, колонки, ключи и индексы
+func (s *Service) GetSchema(ctx context.Context, user, connID string) ([]shema.DBSchema, error) {
+	const op = "service.GetSchema"
+	conn, err := s.resolve(user, connID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pools.Acquire(conn.Conn)()
+	res, err := catalog.Load(ctx, conn.Conn, dialect.Dialect(conn.TypeDB))
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("%w: can't read schema: %v", constants.ErrQueryFailed, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+)
+
+// register открывает (или переиспользует) пул и добавляет подключение пользователю
+func (s *Service) register(user string, c shema.Connection, driver, dsn string) (shema.Connection, error) {
+	db, err := s.pools.Open(driver, dsn)
+	if err != nil {
+		return shema.Connection{}, err
+	}
+	c.Conn = db
+	c, added := s.connections.Add(user, c)
+	if !added {
+		// у пользователя уже есть это подключение, лишняя ссылка на пул не нужна
+		s.pools.Release(db)
+	}
+	return c, nil
+}
+
+// resolve возвращает выбранное в запросе подключение, а без connID - последнее активное,
+// и продлевает жизнь его пулу
+func (s *Service) resolve(user, connID string) (shema.Connection, error) {
+	var conn shema.Connection
+	var err error
+	if connID == "" {
+		conn, err = s.connections.Active(user)
+	} else {
+		conn, err = s.connections.Get(user, connID)
+		if err == nil && !conn.Flag {
+			err = constants.ErrNoConnection
+		}
+	}
+	if err != nil {
+		return shema.Connection{}, err
+	}
+	s.pools.Touch(conn.Conn)
+	return conn, nil
+}
+
+// ListConnections возвращает активные подключения пользователя
+func (s *Service) ListConnections(user string) []shema.Connection {
+	res := make([]shema.Connection, 0)
+	for _, c := range s.connections.List(user) {
+		if c.Flag {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// closeWhere закрывает открытые подключения пользователя, подходящие под match, - после отзыва доступа
+func (s *Service) closeWhere(user string, match func(shema.Connection) bool) {
+	for _, c := range s.connections.List(user) {
+		if !match(c) {
+			continue
+		}
+		if removed, err := s.connections.Remove(user, c.ID); err == nil {
+			s.release(removed)
+		}
+	}
+}
+
+// CloseConnection удаляет подключение пользователя и освобождает его пул
+func (s *Service) CloseConnection(user, connID string) error {
+	const op = "service.CloseConnection"
+	c, err := s.connections.Remove(user, connID)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.release(c)
+	return nil
+}
+
+func (s *Service) release(conns ...shema.Connection) {
+	const op = "service.release"
+	for _, c := range conns {
+		if err := s.pools.Release(c.Conn); err != nil {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		}
+	}
+}
+
+// evicted вызывается менеджером пулов, когда простаивающий пул закрыт
+func (s *Service) evicted(db *sql.DB) {
+	const op = "service.evicted"
+	n := s.connections.RemoveConn(db)
+	s.logger.Info(fmt.Sprintf("%s : closed idle pool used by %d connections", op, n))
+}
+
+// PoolCount возвращает количество открытых пулов к пользовательским базам
+func (s *Service) PoolCount() int {
+	return s.pools.Len()
+}
+
+// auditConnection пишет в журнал создание или открытие подключения
+func (s *Service) auditConnection(ctx context.Context, user, action string, c shema.Connection, err error) {
+	e := shema.AuditEvent{
+		Actor:        user,
+		Action:       action,
+		Success:      err == nil,
+		ConnectionID: c.SavedID,
+		TypeDB:       c.TypeDB,
+		DBName:       c.DBName,
+	}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	s.audit.Record(ctx, e)
+}
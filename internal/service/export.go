@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"smartTables/internal/dialect"
+	"smartTables/internal/export"
+	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
+	"time"
+)
+
+var errNotExportable = errors.New("export is only supported for read queries")
+
+// Export выполняет читающий запрос заново и пишет строки в формате opts прямо из *sql.Rows.
+// open вызывается, когда запрос уже выполнен, - до этого можно вернуть ошибку обычным ответом,
+// после - ответ уже начат и ошибка только обрывает файл.
+func (s *Service) Export(ctx context.Context, user string, req shema.QueryRequest, opts shema.ExportOptions, open func() io.Writer) error {
+	const op = "service.Export"
+	conn, err := s.resolve(user, req.ConnID)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	opts, err = export.Normalize(opts)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+
+	d := dialect.Dialect(conn.TypeDB)
+	query, args, err := bindParams(req.Query, d, req.Params)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	// экспорт перезапускает запрос, поэтому пишущие операторы не допускаются
+	statements := sqlparse.Split(query, d)
+	if len(statements) != 1 || sqlparse.Classify(statements[0], d).Kind != sqlparse.KindRead {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, errNotExportable))
+		return errNotExportable
+	}
+
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, req)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	defer finish()
+
+	started := time.Now()
+	var count int64
+	stream := func(q querier) error {
+		var err error
+		count, err = exportRows(runCtx, query, q, opts, open, args...)
+		return err
+	}
+	if conn.ReadOnly {
+		err = execReadOnly(runCtx, dbConn, d, stream)
+	} else {
+		err = stream(dbConn)
+	}
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		err = queryErr(ctx, runCtx, err)
+	}
+	s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceExport, req.Query, started, count), err)
+	return err
+}
+
+// exportRows пишет строки результата и возвращает их количество
+func exportRows(ctx context.Context, query string, q querier, opts shema.ExportOptions, open func() io.Writer, args ...interface{}) (int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	columns := make([]shema.Column, len(types))
+	for i, t := range types {
+		columns[i] = shema.Column{Name: t.Name(), DatabaseType: t.DatabaseTypeName()}
+	}
+
+	w, err := export.New(open(), opts)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(types))
+	pointers := make([]interface{}, len(types))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	row := make([]shema.Cell, len(types))
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return 0, err
+		}
+		for i, val := range values {
+			row[i] = newCell(val)
+		}
+		if err := w.WriteRow(row); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return count, w.Close()
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartTables/internal/catalog"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"strings"
+	"time"
+)
+
+// loadTable читает колонки и первичный ключ таблицы; без схемы берется первая таблица с таким именем
+func loadTable(ctx context.Context, conn shema.Connection, schema, name string) (shema.Table, error) {
+	t, err := catalog.LoadTable(ctx, conn.Conn, dialect.Dialect(conn.TypeDB), schema, name)
+	if err != nil {
+		return shema.Table{}, fmt.Errorf("%w: can't read schema: %v", constants.ErrQueryFailed, err)
+	}
+	if t == nil {
+		return shema.Table{}, fmt.Errorf("%w: table %s", constants.ErrNotFound, name)
+	}
+	return *t, nil
+}
+
+func hasColumn(t shema.Table, name string) bool {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func tableName(d dialect.Dialect, t shema.Table) string {
+	return d.Quote(t.Schema) + "." + d.Quote(t.Name)
+}
+
+// gridQuery строит SELECT страницы таблицы. Имена колонок сверяются с каталогом и экранируются,
+// значения фильтров передаются параметрами.
+func gridQuery(d dialect.Dialect, t shema.Table, req shema.GridRequest, page shema.Page) (string, []interface{}, error) {
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("SELECT * FROM " + tableName(d, t))
+
+	for i, f := range req.Filters {
+		if !hasColumn(t, f.Column) {
+			return "", nil, fmt.Errorf("unknown column %q", f.Column)
+		}
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		col := d.Quote(f.Column)
+		switch f.Op {
+		case shema.FilterNull:
+			sb.WriteString(col + " IS NULL")
+			continue
+		case shema.FilterNotNull:
+			sb.WriteString(col + " IS NOT NULL")
+			continue
+		case shema.FilterEq, shema.FilterLt, shema.FilterLe, shema.FilterGt, shema.FilterGe:
+			sb.WriteString(col + " " + f.Op + " ")
+		case shema.FilterNe:
+			sb.WriteString(col + " <> ")
+		case shema.FilterLike:
+			if d == dialect.Postgres {
+				// в Postgres LIKE есть только у строк
+				col = "CAST(" + col + " AS text)"
+			}
+			sb.WriteString(col + " LIKE ")
+		default:
+			return "", nil, fmt.Errorf("unknown filter %q", f.Op)
+		}
+		args = append(args, f.Value)
+		sb.WriteString(d.Placeholder(len(args)))
+	}
+
+	order := t.PrimaryKey
+	if req.Sort != "" {
+		if !hasColumn(t, req.Sort) {
+			return "", nil, fmt.Errorf("unknown column %q", req.Sort)
+		}
+		// первичный ключ после колонки сортировки делает порядок страниц устойчивым
+		order = append([]string{req.Sort}, t.PrimaryKey...)
+	}
+	for i, col := range order {
+		if i == 0 {
+			sb.WriteString(" ORDER BY ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(d.Quote(col))
+		if req.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+
+	fmt.Fprintf(&sb, " LIMIT %d OFFSET %d", page.Limit+1, page.Offset)
+	return sb.String(), args, nil
+}
+
+// TableData возвращает страницу строк таблицы с сортировкой и фильтрами
+func (s *Service) TableData(ctx context.Context, user string, req shema.GridRequest) (*shema.GridResult, error) {
+	const op = "service.TableData"
+	conn, err := s.resolve(user, req.ConnID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pools.Acquire(conn.Conn)()
+	table, err := loadTable(ctx, conn, req.Schema, req.Table)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	page, err := s.page(req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	d := dialect.Dialect(conn.TypeDB)
+	query, args, err := gridQuery(d, table, req, page)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, shema.QueryRequest{Query: query})
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	started := time.Now()
+	var res *shema.QueryResult
+	read := func(q querier) error {
+		var err error
+		res, err = ExecWithRes(runCtx, query, q, shema.Page{Limit: page.Limit}, args...)
+		return err
+	}
+	if conn.ReadOnly {
+		err = execReadOnly(runCtx, dbConn, d, read)
+	} else {
+		err = read(dbConn)
+	}
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		err = queryErr(ctx, runCtx, err)
+		s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceGrid, query, started, 0), err)
+		return nil, err
+	}
+	s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceGrid, query, started, int64(len(res.Rows))), nil)
+	res.Offset = page.Offset
+	res.Pageable = true
+	if args == nil {
+		args = []interface{}{}
+	}
+	return &shema.GridResult{Table: table, ReadOnly: conn.ReadOnly, Result: res, Query: query, Args: args}, nil
+}
+
+// editStatement строит UPDATE, INSERT или DELETE одной строки. Строка для update и delete
+// ищется по всем колонкам первичного ключа, поэтому таблицы без ключа так менять нельзя.
+func editStatement(d dialect.Dialect, t shema.Table, edit shema.RowEdit) (*shema.EditStatement, error) {
+	if t.Kind != shema.TableKindTable {
+		return nil, fmt.Errorf("%s is a %s, only tables can be edited", t.Name, t.Kind)
+	}
+	for col := range edit.Values {
+		if !hasColumn(t, col) {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+	}
+
+	var args []interface{}
+	arg := func(v *string) string {
+		if v == nil {
+			args = append(args, nil)
+		} else {
+			args = append(args, *v)
+		}
+		return d.Placeholder(len(args))
+	}
+	// колонки в порядке таблицы, чтобы запрос не зависел от порядка обхода map
+	var cols []string
+	for _, c := range t.Columns {
+		if _, ok := edit.Values[c.Name]; ok {
+			cols = append(cols, c.Name)
+		}
+	}
+	where := func() (string, error) {
+		if len(t.PrimaryKey) == 0 {
+			return "", fmt.Errorf("table %s has no primary key", t.Name)
+		}
+		conds := make([]string, len(t.PrimaryKey))
+		for i, col := range t.PrimaryKey {
+			v, ok := edit.Key[col]
+			if !ok || v == nil {
+				return "", fmt.Errorf("key column %q is required", col)
+			}
+			conds[i] = d.Quote(col) + " = " + arg(v)
+		}
+		return " WHERE " + strings.Join(conds, " AND "), nil
+	}
+
+	var query string
+	switch edit.Action {
+	case shema.EditUpdate:
+		if len(cols) == 0 {
+			return nil, errors.New("nothing to update")
+		}
+		set := make([]string, len(cols))
+		for i, col := range cols {
+			set[i] = d.Quote(col) + " = " + arg(edit.Values[col])
+		}
+		cond, err := where()
+		if err != nil {
+			return nil, err
+		}
+		query = "UPDATE " + tableName(d, t) + " SET " + strings.Join(set, ", ") + cond
+	case shema.EditInsert:
+		switch {
+		case len(cols) > 0:
+			names := make([]string, len(cols))
+			values := make([]string, len(cols))
+			for i, col := range cols {
+				names[i] = d.Quote(col)
+				values[i] = arg(edit.Values[col])
+			}
+			query = "INSERT INTO " + tableName(d, t) + " (" + strings.Join(names, ", ") + ") VALUES (" + strings.Join(values, ", ") + ")"
+		case d == dialect.MySQL:
+			query = "INSERT INTO " + tableName(d, t) + " () VALUES ()"
+		default:
+			query = "INSERT INTO " + tableName(d, t) + " DEFAULT VALUES"
+		}
+	case shema.EditDelete:
+		cond, err := where()
+		if err != nil {
+			return nil, err
+		}
+		query = "DELETE FROM " + tableName(d, t) + cond
+	default:
+		return nil, fmt.Errorf("unknown action %q", edit.Action)
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	return &shema.EditStatement{Query: query, Args: args}, nil
+}
+
+// PreviewEdit возвращает запрос, который выполнит ApplyEdit, не меняя данных
+func (s *Service) PreviewEdit(ctx context.Context, user string, edit shema.RowEdit) (*shema.EditStatement, error) {
+	const op = "service.PreviewEdit"
+	conn, err := s.resolve(user, edit.ConnID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pools.Acquire(conn.Conn)()
+	table, err := loadTable(ctx, conn, edit.Schema, edit.Table)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	return editStatement(dialect.Dialect(conn.TypeDB), table, edit)
+}
+
+// ApplyEdit меняет одну строку таблицы. Если update или delete задели больше одной строки,
+// транзакция откатывается.
+func (s *Service) ApplyEdit(ctx context.Context, user string, edit shema.RowEdit) (*shema.EditStatement, int64, error) {
+	const op = "service.ApplyEdit"
+	conn, err := s.resolve(user, edit.ConnID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer s.pools.Acquire(conn.Conn)()
+	if conn.ReadOnly {
+		return nil, 0, fmt.Errorf("%w: %s is not allowed", constants.ErrReadOnly, strings.ToUpper(edit.Action))
+	}
+	table, err := loadTable(ctx, conn, edit.Schema, edit.Table)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, 0, err
+	}
+	d := dialect.Dialect(conn.TypeDB)
+	stmt, err := editStatement(d, table, edit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	started := time.Now()
+	affected, err := s.applyEdit(ctx, user, conn, d, edit.Action, stmt)
+	s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceEdit, stmt.Query, started, affected), err)
+	if err != nil {
+		return nil, 0, err
+	}
+	return stmt, affected, nil
+}
+
+// applyEdit выполняет изменение в транзакции и возвращает число затронутых строк (-1, если драйвер его не знает)
+func (s *Service) applyEdit(ctx context.Context, user string, conn shema.Connection, d dialect.Dialect, action string, stmt *shema.EditStatement) (int64, error) {
+	const op = "service.ApplyEdit"
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, shema.QueryRequest{Query: stmt.Query})
+	if err != nil {
+		return 0, err
+	}
+	defer finish()
+
+	tx, err := dbConn.BeginTx(runCtx, nil)
+	if err != nil {
+		return 0, queryErr(ctx, runCtx, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(runCtx, stmt.Query, stmt.Args...)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return 0, queryErr(ctx, runCtx, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		affected = -1
+	}
+	if err := checkAffected(d, action, affected); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, queryErr(ctx, runCtx, err)
+	}
+	return affected, nil
+}
+
+// checkAffected проверяет, что изменение по первичному ключу задело ровно одну строку
+func checkAffected(d dialect.Dialect, action string, affected int64) error {
+	if action == shema.EditInsert || affected < 0 {
+		return nil
+	}
+	switch {
+	case affected > 1:
+		return fmt.Errorf("key matched %d rows, changes rolled back", affected)
+	case affected == 0 && action == shema.EditUpdate && d == dialect.MySQL:
+		// MySQL не считает строку измененной, если новые значения совпали со старыми
+		return nil
+	case affected == 0:
+		return fmt.Errorf("%w: no row matches the key", constants.ErrNotFound)
+	}
+	return nil
+}
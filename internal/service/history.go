@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartTables/internal/audit"
+	"smartTables/internal/clientip"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit   = 50
+	maxListLimit       = 500
+	historySaveTimeout = 5 * time.Second
+)
+
+// historyStatus определяет статус выполнения по ошибке
+func historyStatus(err error) string {
+	switch {
+	case err == nil:
+		return shema.HistoryOK
+	case errors.Is(err, constants.ErrQueryTimeout):
+		return shema.HistoryTimeout
+	case errors.Is(err, constants.ErrQueryCanceled):
+		return shema.HistoryCanceled
+	}
+	return shema.HistoryError
+}
+
+// historyEntry начинает запись истории; длительность считается от started
+func historyEntry(source, query string, started time.Time, rows int64) shema.HistoryEntry {
+	duration := time.Since(started).Milliseconds()
+	return shema.HistoryEntry{
+		Query:      query,
+		Time:       started,
+		Source:     source,
+		DurationMs: &duration,
+		Rows:       &rows,
+	}
+}
+
+// recordHistory пишет выполнение на подключении conn в историю пользователя и в журнал аудита;
+// журналом аудита служит только audit_log, история - для поиска и повтора запросов.
+// Ошибка записи только логируется, чтобы не терять результат запроса.
+func (s *Service) recordHistory(ctx context.Context, user string, conn shema.Connection, e shema.HistoryEntry, err error) {
+	const op = "service.recordHistory"
+	e.TypeDB, e.DBName = conn.TypeDB, conn.DBName
+	e.Status = historyStatus(err)
+	e.ClientIP = clientip.From(ctx)
+	if err != nil {
+		e.Error = err.Error()
+		e.Rows = nil
+	}
+
+	// запрос мог быть отменен вместе с контекстом, а запись в историю нужна все равно
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), historySaveTimeout)
+	defer cancel()
+	if err := s.storage.SaveQuery(saveCtx, user, e); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+	}
+
+	action := shema.AuditQuery
+	if e.Source == shema.HistorySourceExport {
+		action = shema.AuditExport
+	}
+	detail := e.Source + " " + e.Status
+	if err != nil {
+		detail += ": " + e.Error
+	}
+	s.audit.Record(ctx, shema.AuditEvent{
+		Actor:        user,
+		Action:       action,
+		Success:      err == nil,
+		ConnectionID: conn.SavedID,
+		TypeDB:       conn.TypeDB,
+		DBName:       conn.DBName,
+		QueryHash:    audit.QueryHash(e.Query),
+		ClientIP:     e.ClientIP,
+		Detail:       detail,
+	})
+}
+
+// recordScript пишет в историю каждый выполненный оператор скрипта со своим временем начала,
+// чтобы операторы в истории шли в порядке выполнения
+func (s *Service) recordScript(ctx context.Context, user string, conn shema.Connection, res *shema.ScriptResult) {
+	for _, r := range res.Statements {
+		rows := r.RowsAffected
+		if r.Result != nil {
+			rows = int64(len(r.Result.Rows))
+		}
+		e := historyEntry(shema.HistorySourceScript, r.Query, r.Started, rows)
+		duration := r.DurationMs
+		e.DurationMs = &duration
+
+		var err error
+		if r.Error != "" {
+			err = errors.New(r.Error)
+		} else if res.RolledBack {
+			e.Error = "transaction rolled back"
+		}
+		s.recordHistory(ctx, user, conn, e, err)
+	}
+}
+
+// checkDates проверяет границы периода в формате YYYY-MM-DD; пустая граница не ограничивает
+func checkDates(dates ...string) error {
+	for _, date := range dates {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("bad date %q, expected YYYY-MM-DD", date)
+		}
+	}
+	return nil
+}
+
+// listLimit проверяет сдвиг и размер страницы списка и подставляет размер по умолчанию
+func listLimit(offset, limit int) (int, error) {
+	if offset < 0 || limit < 0 {
+		return 0, errors.New("offset and limit must not be negative")
+	}
+	if limit == 0 {
+		return defaultListLimit, nil
+	}
+	return min(limit, maxListLimit), nil
+}
+
+// GetHistory ищет в истории пользователя; новые записи первыми
+func (s *Service) GetHistory(ctx context.Context, user string, filter shema.HistoryFilter) (*shema.HistoryPage, error) {
+	const op = "service.GetHistory"
+	filter.Search = strings.TrimSpace(filter.Search)
+	if err := checkDates(filter.From, filter.To); err != nil {
+		return nil, err
+	}
+	limit, err := listLimit(filter.Offset, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+
+	entries, err := s.storage.GetHistory(ctx, user, filter)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get history: %w", err)
+	}
+	page := &shema.HistoryPage{Entries: entries, Offset: filter.Offset, Limit: filter.Limit}
+	if len(entries) > filter.Limit {
+		page.Entries, page.HasMore = entries[:filter.Limit], true
+	}
+	return page, nil
+}
+
+// HistoryDatabases возвращает подключения из истории пользователя для фильтра
+func (s *Service) HistoryDatabases(ctx context.Context, user string) ([]shema.HistoryDatabase, error) {
+	const op = "service.HistoryDatabases"
+	res, err := s.storage.GetHistoryDatabases(ctx, user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get history: %w", err)
+	}
+	return res, nil
+}
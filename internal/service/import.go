@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/importer"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	importPreviewRows = 20
+	// importSampleRows - по скольким первым строкам определяются типы колонок
+	importSampleRows = 1000
+	importTTL        = time.Hour
+	importBatchRows  = 500
+)
+
+// ER_NOT_ALLOWED_COMMAND и ER_CLIENT_LOCAL_FILES_DISABLED - LOAD DATA LOCAL выключен на сервере
+const (
+	mysqlNotAllowedCommand = 1148
+	mysqlLocalFilesOff     = 3948
+)
+
+// PreviewImport сохраняет загруженный CSV/TSV/XLSX, определяет типы колонок и возвращает первые строки.
+// Сам импорт выполняется Import по токену из предпросмотра.
+func (s *Service) PreviewImport(ctx context.Context, user string, file *multipart.FileHeader, opts shema.ImportOptions) (*shema.ImportPreview, error) {
+	const op = "service.PreviewImport"
+	if file == nil {
+		return nil, fmt.Errorf("missing file")
+	}
+	conn, err := s.resolve(user, opts.ConnID)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	if conn.ReadOnly {
+		return nil, fmt.Errorf("%w: import is not allowed", constants.ErrReadOnly)
+	}
+	opts, err = importer.Normalize(file.Filename, opts)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+
+	userDir, err := createUserDir(user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't create user dir: %w", err)
+	}
+	importDir := filepath.Join(userDir, "imports")
+	if err := os.MkdirAll(importDir, 0755); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't create import dir: %w", err)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// имя файла от пользователя не используем, чтобы не выйти за пределы каталога
+	dst, err := saveFile(importDir, strconv.FormatInt(time.Now().UnixNano(), 10)+"."+opts.Format, f)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't save file: %w", err)
+	}
+
+	preview, err := s.preview(ctx, conn, dst, opts)
+	if err != nil {
+		os.Remove(dst)
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	preview.Token = s.imports.Put(user, dst, opts)
+	return preview, nil
+}
+
+func (s *Service) preview(ctx context.Context, conn shema.Connection, path string, opts shema.ImportOptions) (*shema.ImportPreview, error) {
+	src, err := importer.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	header, first, err := importer.ReadHeader(src, opts.NoHeader)
+	if err != nil {
+		return nil, err
+	}
+	sample := make([][]string, 0)
+	if first != nil {
+		sample = append(sample, first)
+	}
+	for len(sample) < importSampleRows {
+		row, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sample = append(sample, append([]string(nil), row...))
+	}
+	types := importer.Infer(sample, len(header))
+
+	preview := &shema.ImportPreview{
+		Table:  opts.Table,
+		Append: opts.Append,
+		ConnID: conn.ID,
+		Header: header,
+		Rows:   sample,
+		Types:  importer.Types,
+	}
+	if len(preview.Rows) > importPreviewRows {
+		preview.Rows = preview.Rows[:importPreviewRows]
+	}
+	if opts.Append {
+		defer s.pools.Acquire(conn.Conn)()
+		preview.Targets, err = tableColumns(ctx, conn.Conn, dialect.Dialect(conn.TypeDB), opts.Table)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", constants.ErrQueryFailed, err)
+		}
+	}
+	for i, name := range header {
+		col := shema.ImportColumn{Source: i, Name: name, Type: types[i]}
+		if opts.Append {
+			// при дописывании сопоставляем колонки по имени без учета регистра
+			col.Name = ""
+			for _, target := range preview.Targets {
+				if strings.EqualFold(target, name) {
+					col.Name = target
+					break
+				}
+			}
+		}
+		preview.Columns = append(preview.Columns, col)
+	}
+	return preview, nil
+}
+
+// tableColumns возвращает имена колонок таблицы, не читая строк
+func tableColumns(ctx context.Context, db *sql.DB, d dialect.Dialect, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+d.QuoteQualified(table)+" WHERE 1 = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// Import создает таблицу (или дописывает в существующую) и загружает в нее файл из предпросмотра.
+// Postgres получает данные через COPY, MySQL - через LOAD DATA LOCAL, если сервер его разрешает,
+// остальное вставляется пачками INSERT. Загрузка идет в одной транзакции; в MySQL CREATE TABLE
+// фиксируется сразу, поэтому при ошибке созданная таблица удаляется отдельно.
+func (s *Service) Import(ctx context.Context, user string, req shema.ImportRequest) (*shema.ImportResult, error) {
+	const op = "service.Import"
+	path, opts, err := s.imports.Get(user, req.Token)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	conn, err := s.resolve(user, req.ConnID)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	if conn.ReadOnly {
+		return nil, fmt.Errorf("%w: import is not allowed", constants.ErrReadOnly)
+	}
+	d := dialect.Dialect(conn.TypeDB)
+	if err := checkImport(req, d); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+
+	query := "IMPORT INTO " + req.Table
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, shema.QueryRequest{Query: query, Timeout: req.Timeout})
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	defer finish()
+
+	started := time.Now()
+	res, err := loadImport(runCtx, dbConn, d, path, opts, req)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		err = queryErr(ctx, runCtx, err)
+		s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceImport, query, started, 0), err)
+		return nil, err
+	}
+	s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceImport, query, started, res.Rows), nil)
+	s.imports.Remove(req.Token)
+	return res, nil
+}
+
+func checkImport(req shema.ImportRequest, d dialect.Dialect) error {
+	if strings.TrimSpace(req.Table) == "" {
+		return fmt.Errorf("table name is required")
+	}
+	if len(req.Columns) == 0 {
+		return fmt.Errorf("no columns to import")
+	}
+	for _, col := range req.Columns {
+		if col.Name == "" || col.Source < 0 {
+			return fmt.Errorf("bad column mapping %+v", col)
+		}
+		if !req.Append {
+			if _, err := importer.SQLType(col.Type, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadImport(ctx context.Context, conn *sql.Conn, d dialect.Dialect, path string, opts shema.ImportOptions, req shema.ImportRequest) (_ *shema.ImportResult, err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if !req.Append {
+		defs := make([]string, len(req.Columns))
+		for i, col := range req.Columns {
+			sqlType, _ := importer.SQLType(col.Type, d)
+			defs[i] = d.Quote(col.Name) + " " + sqlType
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", d.QuoteQualified(req.Table), strings.Join(defs, ", "))); err != nil {
+			return nil, err
+		}
+		if d == dialect.MySQL {
+			defer func() {
+				if err != nil {
+					dropImportTable(ctx, conn, tx, d, req.Table)
+				}
+			}()
+		}
+	}
+
+	rows, err := openImportRows(path, opts, req.Columns, d)
+	if err != nil {
+		return nil, err
+	}
+	// rows может быть открыт заново при переходе на INSERT
+	defer func() { rows.Close() }()
+
+	res := &shema.ImportResult{Table: req.Table}
+	switch d {
+	case dialect.Postgres:
+		res.Method = "copy"
+		res.Rows, err = copyRows(ctx, tx, req.Table, req.Columns, rows)
+	case dialect.MySQL:
+		res.Method = "load data"
+		res.Rows, err = loadDataRows(ctx, tx, req.Token, d, req.Table, req.Columns, rows)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && (mysqlErr.Number == mysqlNotAllowedCommand || mysqlErr.Number == mysqlLocalFilesOff) {
+			rows.Close()
+			if rows, err = openImportRows(path, opts, req.Columns, d); err != nil {
+				return nil, err
+			}
+			res.Method = "insert"
+			res.Rows, err = insertRows(ctx, tx, d, req.Table, req.Columns, rows)
+		}
+	default:
+		res.Method = "insert"
+		res.Rows, err = insertRows(ctx, tx, d, req.Table, req.Columns, rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// dropImportTable удаляет таблицу, созданную неудачным импортом: в MySQL CREATE TABLE
+// фиксируется неявно и откат транзакции его не отменяет
+func dropImportTable(ctx context.Context, conn *sql.Conn, tx *sql.Tx, d dialect.Dialect, table string) {
+	tx.Rollback()
+	// контекст импорта мог истечь, а таблицу убрать нужно все равно
+	dropCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+	conn.ExecContext(dropCtx, "DROP TABLE IF EXISTS "+d.QuoteQualified(table))
+}
+
+// importRows отдает строки файла, уже разложенные по колонкам импорта
+type importRows struct {
+	src     importer.Source
+	pending []string
+	columns []shema.ImportColumn
+	d       dialect.Dialect
+	line    int
+}
+
+func openImportRows(path string, opts shema.ImportOptions, columns []shema.ImportColumn, d dialect.Dialect) (*importRows, error) {
+	src, err := importer.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, first, err := importer.ReadHeader(src, opts.NoHeader)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return &importRows{src: src, pending: first, columns: columns, d: d}, nil
+}
+
+func (r *importRows) Next() ([]interface{}, error) {
+	row := r.pending
+	r.pending = nil
+	if row == nil {
+		var err error
+		if row, err = r.src.Next(); err != nil {
+			return nil, err
+		}
+	}
+	r.line++
+	values := make([]interface{}, len(r.columns))
+	for i, col := range r.columns {
+		if col.Source < len(row) {
+			values[i] = importer.Value(row[col.Source], col.Type, r.d)
+		}
+	}
+	return values, nil
+}
+
+func (r *importRows) Close() error {
+	return r.src.Close()
+}
+
+func importNames(columns []shema.ImportColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []shema.ImportColumn, rows *importRows) (int64, error) {
+	copySQL := pq.CopyIn(table, importNames(columns)...)
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		copySQL = pq.CopyInSchema(schema, name, importNames(columns)...)
+	}
+	stmt, err := tx.PrepareContext(ctx, copySQL)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var n int64
+	for {
+		values, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, fmt.Errorf("row %d: %w", rows.line, err)
+		}
+		n++
+	}
+	// пустой Exec завершает COPY
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// loadDataRows передает строки в LOAD DATA LOCAL через io.Pipe, файл на диске сервера не нужен
+func loadDataRows(ctx context.Context, tx *sql.Tx, token string, d dialect.Dialect, table string, columns []shema.ImportColumn, rows *importRows) (int64, error) {
+	name := "smart_tables_import_" + token
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+
+	done := make(chan error, 1)
+	go func() {
+		err := writeLoadData(pw, rows)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.Quote(col.Name)
+	}
+	query := fmt.Sprintf(`LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`,
+		name, d.QuoteQualified(table), strings.Join(quoted, ", "))
+	res, err := tx.ExecContext(ctx, query)
+	// если драйвер не дочитал поток, писатель не должен остаться заблокированным
+	pr.CloseWithError(io.ErrClosedPipe)
+	werr := <-done
+	if err != nil {
+		return 0, err
+	}
+	if werr != nil {
+		return 0, werr
+	}
+	return res.RowsAffected()
+}
+
+var loadDataEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+func writeLoadData(w io.Writer, rows *importRows) error {
+	var line strings.Builder
+	for {
+		values, err := rows.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line.Reset()
+		for i, v := range values {
+			if i > 0 {
+				line.WriteByte('\t')
+			}
+			switch v := v.(type) {
+			case nil:
+				line.WriteString(`\N`)
+			case int64:
+				line.WriteString(strconv.FormatInt(v, 10))
+			case string:
+				line.WriteString(loadDataEscaper.Replace(v))
+			}
+		}
+		line.WriteByte('\n')
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+}
+
+// insertRows вставляет строки пачками, не превышая лимит параметров запроса
+func insertRows(ctx context.Context, tx *sql.Tx, d dialect.Dialect, table string, columns []shema.ImportColumn, rows *importRows) (int64, error) {
+	maxParams := 65535
+	if d == dialect.SQLite {
+		maxParams = 999
+	}
+	batch := maxParams / len(columns)
+	if batch > importBatchRows {
+		batch = importBatchRows
+	}
+	if batch < 1 {
+		return 0, fmt.Errorf("too many columns: %d", len(columns))
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.Quote(col.Name)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", d.QuoteQualified(table), strings.Join(quoted, ", "))
+
+	var n int64
+	args := make([]interface{}, 0, batch*len(columns))
+	flush := func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		count := len(args) / len(columns)
+		var b strings.Builder
+		b.WriteString(prefix)
+		param := 1
+		for r := 0; r < count; r++ {
+			if r > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('(')
+			for c := range columns {
+				if c > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(d.Placeholder(param))
+				param++
+			}
+			b.WriteByte(')')
+		}
+		if _, err := tx.ExecContext(ctx, b.String(), args...); err != nil {
+			return fmt.Errorf("rows %d-%d: %w", n+1, n+int64(count), err)
+		}
+		n += int64(count)
+		args = args[:0]
+		return nil
+	}
+
+	for {
+		values, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, values...)
+		if len(args) == batch*len(columns) {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
+)
+
+const defaultMaxRows = 1000
+
+// mysqlDupFieldName - ER_DUP_FIELDNAME, производная таблица не допускает одинаковых имен колонок
+const mysqlDupFieldName = 1060
+
+var errNotPageable = errors.New("pagination is only supported for read queries")
+
+func (s *Service) maxRows() int {
+	if s.config.QueryMaxRows > 0 {
+		return s.config.QueryMaxRows
+	}
+	return defaultMaxRows
+}
+
+// page проверяет запрошенную страницу; размер страницы не больше лимита из конфига
+func (s *Service) page(offset, limit int) (shema.Page, error) {
+	if offset < 0 || limit < 0 {
+		return shema.Page{}, fmt.Errorf("offset and limit must not be negative")
+	}
+	if max := s.maxRows(); limit == 0 || limit > max {
+		limit = max
+	}
+	return shema.Page{Offset: offset, Limit: limit}, nil
+}
+
+// pageQuery оборачивает читающий запрос в SELECT с LIMIT/OFFSET, чтобы база сама отдала нужную страницу.
+// Лимит берется на одну строку больше, чтобы узнать, есть ли следующая страница.
+func pageQuery(query string, d dialect.Dialect, page shema.Page) (string, bool) {
+	statements := sqlparse.Split(query, d)
+	if len(statements) != 1 {
+		return "", false
+	}
+	class := sqlparse.Classify(statements[0], d)
+	if class.Kind != sqlparse.KindRead {
+		return "", false
+	}
+	switch class.Keyword {
+	case "SELECT", "VALUES", "TABLE", "WITH":
+	default:
+		// SHOW, EXPLAIN, PRAGMA нельзя использовать как подзапрос
+		return "", false
+	}
+	// перевод строки закрывает возможный комментарий в конце запроса
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS st_page LIMIT %d OFFSET %d", statements[0], page.Limit+1, page.Offset), true
+}
+
+// execPage возвращает страницу результата. Запросы, которые нельзя обернуть,
+// читаются построчно с пропуском первых page.Offset строк.
+func execPage(ctx context.Context, query string, d dialect.Dialect, q querier, page shema.Page, args ...interface{}) (*shema.QueryResult, error) {
+	if wrapped, ok := pageQuery(query, d, page); ok {
+		res, err := ExecWithRes(ctx, wrapped, q, shema.Page{Limit: page.Limit}, args...)
+		var mysqlErr *mysql.MySQLError
+		switch {
+		case err == nil:
+			res.Offset = page.Offset
+			res.Pageable = true
+			return res, nil
+		case d == dialect.MySQL && errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDupFieldName:
+			// SELECT * FROM a JOIN b - читаем исходный запрос
+			res, err := ExecWithRes(ctx, query, q, page, args...)
+			if err != nil {
+				return nil, err
+			}
+			res.Pageable = true
+			return res, nil
+		default:
+			return nil, err
+		}
+	}
+	if page.Offset > 0 {
+		return nil, errNotPageable
+	}
+	return ExecWithRes(ctx, query, q, page, args...)
+}
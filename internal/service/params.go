@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"smartTables/internal/dialect"
+	"smartTables/internal/importer"
+	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
+	"strconv"
+	"time"
+)
+
+var paramTimestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// QueryParams находит в запросе именованные параметры, чтобы редактор показал для них поля
+func (s *Service) QueryParams(user, connID, query string) ([]shema.QueryParam, error) {
+	conn, err := s.resolve(user, connID)
+	if err != nil {
+		return nil, err
+	}
+	found := sqlparse.Params(query, dialect.Dialect(conn.TypeDB))
+	res := make([]shema.QueryParam, len(found))
+	for i, p := range found {
+		res[i] = shema.QueryParam{Name: p.Name, Type: p.Type}
+	}
+	return res, nil
+}
+
+// bindParams заменяет :name и {{name}} плейсхолдерами драйвера и приводит значения к типам.
+// Значения никогда не подставляются в текст запроса.
+func bindParams(query string, d dialect.Dialect, params []shema.QueryParam) (string, []interface{}, error) {
+	found := sqlparse.Params(query, d)
+	if len(found) == 0 {
+		return query, nil, nil
+	}
+	byName := make(map[string]shema.QueryParam, len(params))
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+
+	values := make(map[string]interface{}, len(found))
+	casts := make(map[string]string)
+	for _, p := range found {
+		qp, ok := byName[p.Name]
+		if !ok {
+			return "", nil, fmt.Errorf("no value for parameter %q", p.Name)
+		}
+		if qp.Type == "" {
+			qp.Type = p.Type
+		}
+		v, err := paramValue(qp)
+		if err != nil {
+			return "", nil, err
+		}
+		values[p.Name] = v
+		if qp.Type != "" && d == dialect.Postgres {
+			// lib/pq передает параметры без типа, и там, где Postgres не может его вывести, нужен CAST
+			if casts[p.Name], err = importer.SQLType(qp.Type, d); err != nil {
+				return "", nil, fmt.Errorf("parameter %q: %v", p.Name, err)
+			}
+		}
+	}
+
+	bound, names := sqlparse.Bind(query, d, casts)
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = values[name]
+	}
+	return bound, args, nil
+}
+
+// paramValue приводит строку из формы к типу параметра
+func paramValue(p shema.QueryParam) (interface{}, error) {
+	if p.Null {
+		return nil, nil
+	}
+	switch p.Type {
+	case "", importer.Text:
+		return p.Value, nil
+	case importer.Integer:
+		v, err := strconv.ParseInt(p.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %q is not an integer", p.Name, p.Value)
+		}
+		return v, nil
+	case importer.Float:
+		v, err := strconv.ParseFloat(p.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %q is not a number", p.Name, p.Value)
+		}
+		return v, nil
+	case importer.Boolean:
+		v, err := strconv.ParseBool(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %q is not a boolean", p.Name, p.Value)
+		}
+		return v, nil
+	case importer.Date:
+		if _, err := time.Parse("2006-01-02", p.Value); err != nil {
+			return nil, fmt.Errorf("parameter %q: %q is not a date (YYYY-MM-DD)", p.Name, p.Value)
+		}
+		return p.Value, nil
+	case importer.Timestamp:
+		for _, layout := range paramTimestampLayouts {
+			if v, err := time.Parse(layout, p.Value); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("parameter %q: %q is not a timestamp", p.Name, p.Value)
+	}
+	return nil, fmt.Errorf("parameter %q: unknown type %q", p.Name, p.Type)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/sqlparse"
+)
+
+// checkReadOnly отклоняет скрипт, если в нем есть хотя бы один не читающий оператор
+func checkReadOnly(statements []string, d dialect.Dialect) error {
+	for _, stmt := range statements {
+		if class := sqlparse.Classify(stmt, d); class.Kind != sqlparse.KindRead {
+			return fmt.Errorf("%w: %s statement is not allowed", constants.ErrReadOnly, class.Keyword)
+		}
+	}
+	return nil
+}
+
+// execReadOnly выполняет fn в транзакции только для чтения на выделенном соединении пула.
+// Транзакция всегда откатывается, настройки сессии возвращаются до того, как вызывающий вернет соединение в пул.
+func execReadOnly(ctx context.Context, conn *sql.Conn, d dialect.Dialect, fn func(q querier) error) error {
+	if d == dialect.Postgres {
+		// lib/pq отправляет BEGIN READ ONLY
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		return fn(tx)
+	}
+
+	var enable, disable string
+	switch d {
+	case dialect.MySQL:
+		enable, disable = "SET SESSION TRANSACTION READ ONLY", "SET SESSION TRANSACTION READ WRITE"
+	case dialect.SQLite:
+		enable, disable = "PRAGMA query_only = ON", "PRAGMA query_only = OFF"
+	default:
+		return fmt.Errorf("%w: unsupported database type %s", constants.ErrReadOnly, d)
+	}
+
+	if _, err := conn.ExecContext(ctx, enable); err != nil {
+		return err
+	}
+	defer func() {
+		// контекст запроса мог уже истечь, а сбросить режим нужно в любом случае
+		if _, err := conn.ExecContext(context.Background(), disable); err != nil {
+			// соединение в неизвестном состоянии - выбрасываем его из пула
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"time"
+)
+
+const (
+	defaultQueryTimeout = 60 * time.Second
+	cancelTimeout       = 5 * time.Second
+)
+
+// queryTimeout возвращает таймаут запроса: запрошенный клиентом или из конфига
+func (s *Service) queryTimeout(requested int) (time.Duration, error) {
+	if requested < 0 {
+		return 0, fmt.Errorf("timeout must not be negative")
+	}
+	timeout := defaultQueryTimeout
+	if s.config.QueryTimeout > 0 {
+		timeout = time.Duration(s.config.QueryTimeout) * time.Second
+	}
+	if requested > 0 {
+		timeout = time.Duration(requested) * time.Second
+	}
+	if limit := time.Duration(s.config.QueryMaxTimeout) * time.Second; limit > 0 && timeout > limit {
+		timeout = limit
+	}
+	return timeout, nil
+}
+
+// startQuery берет выделенное соединение из пула и регистрирует запрос в списке выполняющихся.
+// finish нужно вызвать после выполнения, он же возвращает соединение в пул.
+// Пока запрос выполняется, пул не закрывается по простою.
+func (s *Service) startQuery(ctx context.Context, user string, conn shema.Connection, req shema.QueryRequest) (context.Context, *sql.Conn, func(), error) {
+	timeout, err := s.queryTimeout(req.Timeout)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	release := s.pools.Acquire(conn.Conn)
+	dbConn, err := conn.Conn.Conn(ctx)
+	if err != nil {
+		release()
+		return nil, nil, nil, fmt.Errorf("%w: %v", constants.ErrQueryFailed, err)
+	}
+
+	q := shema.RunningQuery{
+		ID:     req.QueryID,
+		ConnID: conn.ID,
+		DBName: conn.DBName,
+		TypeDB: conn.TypeDB,
+		Query:  req.Query,
+		Conn:   conn.Conn,
+	}
+	if dialect.Dialect(conn.TypeDB) == dialect.Postgres {
+		// pid нужен, чтобы отменить запрос на сервере через pg_cancel_backend
+		if err := dbConn.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&q.BackendPID); err != nil {
+			dbConn.Close()
+			release()
+			return nil, nil, nil, fmt.Errorf("%w: %v", constants.ErrQueryFailed, err)
+		}
+	}
+
+	runCtx, done, err := s.running.Start(ctx, user, q, timeout)
+	if err != nil {
+		dbConn.Close()
+		release()
+		return nil, nil, nil, err
+	}
+	finish := func() {
+		done()
+		dbConn.Close()
+		release()
+	}
+	return runCtx, dbConn, finish, nil
+}
+
+// queryErr отличает таймаут и отмену запроса от ошибки самой базы
+func queryErr(ctx, runCtx context.Context, err error) error {
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		return fmt.Errorf("%w: %v", constants.ErrQueryTimeout, err)
+	case errors.Is(runCtx.Err(), context.Canceled) && ctx.Err() == nil:
+		return fmt.Errorf("%w: %v", constants.ErrQueryCanceled, err)
+	}
+	return fmt.Errorf("%w: %v", constants.ErrQueryFailed, err)
+}
+
+// RunningQueries возвращает выполняющиеся запросы пользователя
+func (s *Service) RunningQueries(user string) []shema.RunningQuery {
+	return s.running.List(user)
+}
+
+// CancelQuery отменяет запрос пользователя; в Postgres дополнительно вызывается pg_cancel_backend
+func (s *Service) CancelQuery(user, queryID string) error {
+	const op = "service.CancelQuery"
+	err := s.running.Cancel(user, queryID, func(q shema.RunningQuery) {
+		if q.BackendPID == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+		if _, err := q.Conn.ExecContext(ctx, "SELECT pg_cancel_backend($1)", q.BackendPID); err != nil {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		}
+	})
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	return nil
+}
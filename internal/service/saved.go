@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
+	"sort"
+	"strings"
+)
+
+// ListSavedQueries возвращает запросы пользователя и те, которыми с ним поделились
+func (s *Service) ListSavedQueries(ctx context.Context, user string, filter shema.SavedQueryFilter) ([]shema.SavedQuery, error) {
+	const op = "service.ListSavedQueries"
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	res, err := s.storage.ListSavedQueries(ctx, user, filter)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get saved queries: %w", err)
+	}
+	return res, nil
+}
+
+func (s *Service) GetSavedQuery(ctx context.Context, user string, id int64) (shema.SavedQuery, error) {
+	const op = "service.GetSavedQuery"
+	q, err := s.storage.GetSavedQuery(ctx, user, id)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return q, err
+	}
+	return q, nil
+}
+
+// StoreSavedQuery создает запрос (ID 0) или меняет существующий запрос пользователя
+func (s *Service) StoreSavedQuery(ctx context.Context, user string, q shema.SavedQuery) (int64, error) {
+	const op = "service.StoreSavedQuery"
+	q, err := normalizeSavedQuery(user, q)
+	if err != nil {
+		return 0, err
+	}
+	if q.ID == 0 {
+		q.ID, err = s.storage.CreateSavedQuery(ctx, q)
+	} else {
+		err = s.storage.UpdateSavedQuery(ctx, q)
+	}
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return 0, err
+	}
+	return q.ID, nil
+}
+
+func (s *Service) DeleteSavedQuery(ctx context.Context, user string, id int64) error {
+	const op = "service.DeleteSavedQuery"
+	if err := s.storage.DeleteSavedQuery(ctx, user, id); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	return nil
+}
+
+// RunSavedQuery выполняет сохраненный запрос на подключении req.ConnID.
+// Значения параметров из req перекрывают значения по умолчанию.
+func (s *Service) RunSavedQuery(ctx context.Context, user string, id int64, req shema.QueryRequest) (*shema.QueryResult, shema.QueryRequest, error) {
+	const op = "service.RunSavedQuery"
+	q, err := s.storage.GetSavedQuery(ctx, user, id)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, req, err
+	}
+	conn, err := s.resolve(user, req.ConnID)
+	if err != nil {
+		return nil, req, err
+	}
+	if q.TypeDB != "" && q.TypeDB != conn.TypeDB {
+		return nil, req, fmt.Errorf("saved query %q is written for %s, connection is %s", q.Name, q.TypeDB, conn.TypeDB)
+	}
+
+	req.Query = q.Query
+	req.ConnID = conn.ID
+	req.Params = mergeParams(q.Params, req.Params)
+	res, err := s.ExecQuery(ctx, user, req)
+	return res, req, err
+}
+
+// normalizeSavedQuery проверяет поля и приводит теги и список пользователей к единому виду.
+// Параметры берутся из текста запроса, значения по умолчанию сохраняются по имени.
+func normalizeSavedQuery(user string, q shema.SavedQuery) (shema.SavedQuery, error) {
+	q.Owner = user
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" {
+		return q, errors.New("name is required")
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return q, errors.New("query is required")
+	}
+	switch dialect.Dialect(q.TypeDB) {
+	case "", dialect.Postgres, dialect.MySQL, dialect.SQLite:
+	default:
+		return q, fmt.Errorf("unknown database type %q", q.TypeDB)
+	}
+
+	q.Tags = uniqueSorted(q.Tags, strings.ToLower)
+	q.SharedWith = uniqueSorted(q.SharedWith, func(s string) string { return s })
+	for i, u := range q.SharedWith {
+		if u == user {
+			q.SharedWith = append(q.SharedWith[:i], q.SharedWith[i+1:]...)
+			break
+		}
+	}
+
+	defaults := make(map[string]shema.QueryParam, len(q.Params))
+	for _, p := range q.Params {
+		defaults[p.Name] = p
+	}
+	found := sqlparse.Params(q.Query, dialect.Dialect(q.TypeDB))
+	q.Params = make([]shema.QueryParam, len(found))
+	for i, p := range found {
+		param := shema.QueryParam{Name: p.Name, Type: p.Type}
+		if d, ok := defaults[p.Name]; ok {
+			param.Value, param.Null = d.Value, d.Null
+			if d.Type != "" {
+				param.Type = d.Type
+			}
+		}
+		q.Params[i] = param
+	}
+	return q, nil
+}
+
+// mergeParams дополняет переданные значения параметров значениями по умолчанию
+func mergeParams(defaults, values []shema.QueryParam) []shema.QueryParam {
+	res := make([]shema.QueryParam, 0, len(defaults))
+	given := make(map[string]shema.QueryParam, len(values))
+	for _, p := range values {
+		given[p.Name] = p
+	}
+	for _, p := range defaults {
+		if v, ok := given[p.Name]; ok {
+			if v.Type == "" {
+				v.Type = p.Type
+			}
+			p = v
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func uniqueSorted(values []string, norm func(string) string) []string {
+	seen := make(map[string]bool, len(values))
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		v = norm(strings.TrimSpace(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res
+}
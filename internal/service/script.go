@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"smartTables/internal/dialect"
+	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
+	"time"
+)
+
+// querier - общее у *sql.DB, *sql.Tx и *sql.Conn
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+const scriptSavepoint = "smart_tables_stmt"
+
+// ExecScript выполняет операторы скрипта по очереди на одном соединении dbConn и возвращает результат каждого.
+// Ошибка возвращается только если скрипт отклонен целиком, прерван по таймауту или отмене
+// или не удалось открыть или завершить транзакцию.
+func ExecScript(ctx context.Context, script string, conn shema.Connection, dbConn *sql.Conn, opts shema.ScriptOptions) (*shema.ScriptResult, error) {
+	d := dialect.Dialect(conn.TypeDB)
+	statements := sqlparse.Split(script, d)
+
+	if conn.ReadOnly {
+		if err := checkReadOnly(statements, d); err != nil {
+			return nil, err
+		}
+		var res *shema.ScriptResult
+		err := execReadOnly(ctx, dbConn, d, func(q querier) error {
+			var err error
+			res, err = runScript(ctx, statements, d, q, true, opts)
+			return err
+		})
+		return res, err
+	}
+
+	// скрипт мог оставить открытую транзакцию, SET или временные таблицы - в пул такое соединение не возвращаем
+	defer discardConn(dbConn)
+
+	if !opts.Transaction {
+		return runScript(ctx, statements, d, dbConn, false, opts)
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := runScript(ctx, statements, d, tx, true, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed > 0 && !opts.ContinueOnError {
+		res.RolledBack = true
+		return res, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("can't commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+func runScript(ctx context.Context, statements []string, d dialect.Dialect, q querier, inTx bool, opts shema.ScriptOptions) (*shema.ScriptResult, error) {
+	res := &shema.ScriptResult{Statements: make([]shema.StatementResult, 0, len(statements))}
+	// в транзакции ошибка в Postgres ломает всю транзакцию, поэтому продолжаем после точки сохранения
+	savepoint := inTx && opts.ContinueOnError
+
+	for _, stmt := range statements {
+		if savepoint {
+			if _, err := q.ExecContext(ctx, "SAVEPOINT "+scriptSavepoint); err != nil {
+				return nil, fmt.Errorf("can't create savepoint: %w", err)
+			}
+		}
+
+		started := time.Now()
+		r := execStatement(ctx, stmt, d, q, opts.MaxRows)
+		r.Started, r.DurationMs = started, time.Since(started).Milliseconds()
+		res.Statements = append(res.Statements, r)
+
+		if r.Error != "" && ctx.Err() != nil {
+			// таймаут или отмена: остальные операторы тоже не выполнятся
+			return nil, errors.New(r.Error)
+		}
+		if r.Error != "" {
+			res.Failed++
+			if savepoint {
+				if _, err := q.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+scriptSavepoint); err != nil {
+					return nil, fmt.Errorf("can't rollback to savepoint: %w", err)
+				}
+			}
+			if !opts.ContinueOnError {
+				break
+			}
+		} else if savepoint {
+			if _, err := q.ExecContext(ctx, "RELEASE SAVEPOINT "+scriptSavepoint); err != nil {
+				return nil, fmt.Errorf("can't release savepoint: %w", err)
+			}
+		}
+	}
+	return res, nil
+}
+
+func execStatement(ctx context.Context, stmt string, d dialect.Dialect, q querier, maxRows int) shema.StatementResult {
+	class := sqlparse.Classify(stmt, d)
+	r := shema.StatementResult{Query: stmt, Kind: class.Kind.String()}
+	if class.ReturnsRows {
+		res, err := ExecWithRes(ctx, stmt, q, shema.Page{Limit: maxRows})
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		r.Result = res
+		return r
+	}
+	affected, err := ExecWithoutRes(ctx, stmt, q)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	// SQLite для DDL возвращает счетчик предыдущего оператора
+	if class.Kind == sqlparse.KindWrite {
+		r.RowsAffected = affected
+	}
+	return r
+}
+
+// discardConn закрывает соединение так, что пул его больше не выдаст
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
@@ -14,9 +15,17 @@ import (
 	"os"
 	"path/filepath"
 	"smartTables/config"
+	"smartTables/internal/audit"
 	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
 	"smartTables/internal/domains"
+	"smartTables/internal/importer"
+	"smartTables/internal/pool"
+	"smartTables/internal/registry"
+	"smartTables/internal/running"
+	"smartTables/internal/secret"
 	"smartTables/internal/shema"
+	"smartTables/internal/sqlparse"
 	"strings"
 	"time"
 )
@@ -25,7 +34,12 @@ type Service struct {
 	storage     domains.Storage
 	config      config.Config
 	logger      *zap.Logger
-	connections map[string][]shema.Connection
+	connections *registry.Registry
+	pools       *pool.Manager
+	keyring     *secret.Keyring
+	running     *running.Tracker
+	imports     *importer.Store
+	audit       *audit.Log
 }
 
 func NewService(storage domains.Storage, config config.Config) *Service {
@@ -33,57 +47,171 @@ func NewService(storage domains.Storage, config config.Config) *Service {
 	if err != nil {
 		return nil
 	}
-	return &Service{storage: storage, logger: logger, config: config, connections: make(map[string][]shema.Connection)}
+	pools := pool.New(pool.Options{
+		MaxOpenConns:    config.PoolMaxOpenConns,
+		MaxIdleConns:    config.PoolMaxIdleConns,
+		ConnMaxIdleTime: time.Duration(config.PoolConnMaxIdleTime) * time.Second,
+		IdleTimeout:     time.Duration(config.PoolIdleTimeout) * time.Second,
+	})
+	keyring, err := secret.NewKeyring(config.EncryptionKeyID, config.EncryptionKeys)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("service.NewService : bad encryption keys: %v", err))
+	}
+	if !keyring.Enabled() {
+		logger.Warn("service.NewService : encryption keys are not configured, connection strings are stored in plaintext")
+	}
+	sinks := []audit.Sink{audit.StoreSink(storage)}
+	if config.AuditFile != "" {
+		file, err := audit.OpenFile(config.AuditFile)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("service.NewService : can't open audit file: %v", err))
+		}
+		sinks = append(sinks, file)
+	}
+	s := &Service{storage: storage, logger: logger, config: config, connections: registry.New(), pools: pools, keyring: keyring, running: running.New(), imports: importer.NewStore(importTTL), audit: audit.New(logger, sinks...)}
+	pools.OnEvict(s.evicted)
+	return s
+}
+
+// Close закрывает все пулы к пользовательским базам и файл журнала аудита
+func (s *Service) Close() error {
+	return errors.Join(s.pools.Close(), s.audit.Close())
+}
+
+// ExecQuery выполняет запрос и записывает его в историю вместе со статусом, длительностью и числом строк.
+// Каждая страница результата - отдельное выполнение и отдельная запись.
+func (s *Service) ExecQuery(ctx context.Context, user string, req shema.QueryRequest) (*shema.QueryResult, error) {
+	const op = "service.ExecQuery"
+	conn, err := s.resolve(user, req.ConnID)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+
+	started := time.Now()
+	res, err := s.execQuery(ctx, user, conn, req)
+	var rows int64
+	switch {
+	case res == nil:
+	case res.Columns == nil:
+		rows = res.RowsAffected
+	default:
+		rows = int64(len(res.Rows))
+	}
+	s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceQuery, req.Query, started, rows), err)
+	return res, err
 }
 
-func (s *Service) ExecQuery(ctx context.Context, query string, user string) ([][]string, error) {
+// execQuery возвращает результат выборки, а для остальных операторов - результат с одним RowsAffected
+func (s *Service) execQuery(ctx context.Context, user string, conn shema.Connection, req shema.QueryRequest) (*shema.QueryResult, error) {
 	const op = "service.ExecQuery"
-	var connectionString *sql.DB
-	connection, ok := s.connections[user]
-	for _, conn := range connection {
-		if conn.Flag {
-			connectionString = conn.Conn
+	d := dialect.Dialect(conn.TypeDB)
+	query, args, err := bindParams(req.Query, d, req.Params)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	if conn.ReadOnly {
+		if err := checkReadOnly(sqlparse.Split(query, d), d); err != nil {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+			return nil, err
+		}
+	}
+	page, err := s.page(req.Offset, req.Limit)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
+	}
+	if page.Offset > 0 {
+		// перезапускать со сдвигом можно только читающий запрос, иначе изменения повторятся
+		if _, ok := pageQuery(query, d, page); !ok {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, errNotPageable))
+			return nil, errNotPageable
 		}
 	}
-	if !ok {
-		s.logger.Info(fmt.Sprintf("%s : %v", op, fmt.Errorf("no connection")))
-		return nil, fmt.Errorf("no connections")
+
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, req)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, err
 	}
+	defer finish()
 
-	if strings.Contains(query, "INSERT") || strings.Contains(query, "DELETE") || strings.Contains(query, "UPDATE") {
-		err := ExecWithoutRes(ctx, query, connectionString)
+	if conn.ReadOnly {
+		var res *shema.QueryResult
+		err = execReadOnly(runCtx, dbConn, d, func(q querier) error {
+			var err error
+			res, err = execPage(runCtx, query, d, q, page, args...)
+			return err
+		})
 		if err != nil {
 			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-			return nil, err
+			return nil, queryErr(ctx, runCtx, err)
+		}
+		return res, nil
+	}
+
+	if !sqlparse.Classify(query, d).ReturnsRows {
+		affected, err := ExecWithoutRes(runCtx, query, dbConn, args...)
+		if err != nil {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+			return nil, queryErr(ctx, runCtx, err)
 		}
-		return nil, nil
+		return &shema.QueryResult{Rows: [][]shema.Cell{}, RowsAffected: affected}, nil
 	}
 
-	res, err := ExecWithRes(ctx, query, connectionString)
+	res, err := execPage(runCtx, query, d, dbConn, page, args...)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return nil, err
+		return nil, queryErr(ctx, runCtx, err)
 	}
 
 	return res, nil
 }
-func ExecWithRes(ctx context.Context, query string, connectionString *sql.DB) ([][]string, error) {
-	rows, err := connectionString.QueryContext(ctx, query)
+
+// ExecWithRes читает строки результата, начиная с page.Offset, и не больше page.Limit.
+// Если строк больше, результат помечается как Truncated, остальные строки не читаются.
+func ExecWithRes(ctx context.Context, query string, db querier, page shema.Page, args ...interface{}) (*shema.QueryResult, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	cols, err := rows.Columns()
+	types, err := rows.ColumnTypes()
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([][]string, 0)
+	result := &shema.QueryResult{
+		Columns: make([]shema.Column, len(types)),
+		Rows:    make([][]shema.Cell, 0),
+		Offset:  page.Offset,
+		Limit:   page.Limit,
+	}
+	for i, t := range types {
+		nullable, ok := t.Nullable()
+		result.Columns[i] = shema.Column{
+			Name:          t.Name(),
+			DatabaseType:  t.DatabaseTypeName(),
+			Nullable:      nullable,
+			NullableKnown: ok,
+		}
+	}
+
+	for skipped := 0; skipped < page.Offset; skipped++ {
+		if !rows.Next() {
+			break
+		}
+	}
 
 	for rows.Next() {
-		columns := make([]interface{}, len(cols))
-		columnPointers := make([]interface{}, len(cols))
+		if page.Limit > 0 && len(result.Rows) == page.Limit {
+			result.Truncated = true
+			break
+		}
+		columns := make([]interface{}, len(types))
+		columnPointers := make([]interface{}, len(types))
 		for i := range columns {
 			columnPointers[i] = &columns[i]
 		}
@@ -91,16 +219,11 @@ func ExecWithRes(ctx context.Context, query string, connectionString *sql.DB) ([
 			return nil, err
 		}
 
-		strRow := make([]string, len(cols))
+		row := make([]shema.Cell, len(types))
 		for i, val := range columns {
-			bytes, ok := val.([]byte)
-			if ok {
-				strRow[i] = string(bytes)
-			} else {
-				strRow[i] = fmt.Sprint(val)
-			}
+			row[i] = newCell(val)
 		}
-		result = append(result, strRow)
+		result.Rows = append(result.Rows, row)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -109,77 +232,114 @@ func ExecWithRes(ctx context.Context, query string, connectionString *sql.DB) ([
 	return result, nil
 }
 
-func ExecWithoutRes(ctx context.Context, query string, connectionString *sql.DB) error {
-	_, err := connectionString.ExecContext(ctx, query)
+func newCell(val interface{}) shema.Cell {
+	switch v := val.(type) {
+	case nil:
+		return shema.Cell{Null: true}
+	case []byte:
+		return shema.Cell{Value: string(v), Raw: string(v)}
+	case time.Time:
+		return shema.Cell{Value: v.Format(time.RFC3339Nano), Raw: v}
+	default:
+		return shema.Cell{Value: fmt.Sprint(v), Raw: v}
+	}
+}
+
+func ExecWithoutRes(ctx context.Context, query string, db querier, args ...interface{}) (int64, error) {
+	res, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to do query: %w", err)
+		return 0, fmt.Errorf("failed to do query: %w", err)
 	}
-	return nil
+	affected, err := res.RowsAffected()
+	if err != nil {
+		// не все драйверы умеют считать затронутые строки
+		return 0, nil
+	}
+	return affected, nil
 }
 
-func (s *Service) GetConnection(ctx context.Context, user, typeDB, connect, dbName string) {
+// GetConnection сохраняет и открывает подключение. С teamID подключение принадлежит команде:
+// создать его может только ее участник, а строку подключения остальные участники не увидят.
+func (s *Service) GetConnection(ctx context.Context, user, typeDB, connect, dbName string, readOnly bool, teamID int64) (shema.Connection, error) {
 	const op = "service.GetConnection"
+	if err := s.requireRole(ctx, user, shema.RoleEditor); err != nil {
+		return shema.Connection{}, err
+	}
+	if teamID != 0 {
+		if err := s.requireTeam(ctx, user, teamID); err != nil {
+			return shema.Connection{}, err
+		}
+	}
 	c := shema.Connection{}
 	c.TypeDB = typeDB
-	c.Flag = true
+	c.ReadOnly = readOnly
+	c.TeamID = teamID
 	if dbName == "" {
 		c.DBName = "DatabaseWithoutName"
 	} else {
 		c.DBName = dbName
 	}
-	var driver string
-	switch {
-	case typeDB == "postgresql":
-		driver = "postgres"
-	case typeDB == "mysql":
-		driver = "mysql"
+	driver := dialect.Dialect(typeDB).Driver()
+	sealed, err := s.keyring.Seal(connect)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return shema.Connection{}, fmt.Errorf("can't encrypt connection string: %w", err)
 	}
-	err := s.storage.SaveConnection(ctx, user, typeDB, c.DBName, connect)
+	c.SavedID, err = s.storage.SaveConnection(ctx, user, typeDB, c.DBName, sealed, readOnly, teamID)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return
+		err = fmt.Errorf("can't save connection: %w", err)
+		s.auditConnection(ctx, user, shema.AuditConnectionCreate, c, err)
+		return shema.Connection{}, err
 	}
-	db, err := sql.Open(driver, connect)
+	registered, err := s.register(user, c, driver, connect)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return
+		s.auditConnection(ctx, user, shema.AuditConnectionCreate, c, err)
+		return shema.Connection{}, fmt.Errorf("can't open connection: %w", err)
 	}
-	c.Conn = db
-	s.connections[user] = append(s.connections[user], c)
+	s.auditConnection(ctx, user, shema.AuditConnectionCreate, registered, nil)
+	return registered, nil
 }
 
-func (s *Service) GetConnectionWithFile(user, typeDB, dbName string, file *multipart.FileHeader) {
-	const op = "service.GetConnection"
+func (s *Service) GetConnectionWithFile(ctx context.Context, user, typeDB, dbName string, file *multipart.FileHeader, readOnly bool) (shema.Connection, error) {
+	const op = "service.GetConnectionWithFile"
+	if err := s.requireRole(ctx, user, shema.RoleEditor); err != nil {
+		return shema.Connection{}, err
+	}
 	userDir, err := createUserDir(user)
 	if err != nil {
-		return
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return shema.Connection{}, fmt.Errorf("can't create user dir: %w", err)
 	}
 
 	fileRes, err := file.Open()
 	if err != nil {
-		return
+		return shema.Connection{}, err
 	}
 	defer fileRes.Close()
 
 	dst, err := saveFile(userDir, file.Filename, fileRes)
 	if err != nil {
-		return
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return shema.Connection{}, fmt.Errorf("can't save file: %w", err)
 	}
 	c := shema.Connection{}
 	c.TypeDB = typeDB
-	c.Flag = true
+	c.ReadOnly = readOnly
 	if dbName == "" {
 		c.DBName = "DatabaseWithoutName"
 	} else {
 		c.DBName = dbName
 	}
-	db, err := sql.Open("sqlite3", dst)
+	registered, err := s.register(user, c, dialect.SQLite.Driver(), dst)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return
+		s.auditConnection(ctx, user, shema.AuditConnectionCreate, c, err)
+		return shema.Connection{}, fmt.Errorf("can't open connection: %w", err)
 	}
-	c.Conn = db
-	s.connections[user] = append(s.connections[user], c)
+	s.auditConnection(ctx, user, shema.AuditConnectionCreate, registered, nil)
+	return registered, nil
 }
 func createUserDir(username string) (string, error) {
 	userDir := filepath.Join(".", username)
@@ -209,33 +369,40 @@ func saveFile(userDir string, filename string, file io.Reader) (string, error) {
 	return dst, nil
 }
 
-func (s *Service) GetConnectionFromBtn(ctx context.Context, user, connect, dbName string) (string, error) {
+// GetConnectionFromBtn открывает сохраненное подключение по его ID в таблице connections
+func (s *Service) GetConnectionFromBtn(ctx context.Context, user string, id int64) (shema.Connection, error) {
 	const op = "service.GetConnectionFromBtn"
 
-	c := shema.Connection{}
-	c.Flag = true
-	typeDB, err := s.storage.GetTypeDB(ctx, user, dbName, connect)
+	role, err := s.UserRole(ctx, user)
+	if err != nil {
+		return shema.Connection{}, err
+	}
+	saved, err := s.storage.GetSavedConnection(ctx, user, id)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return "", err
+		return shema.Connection{}, err
 	}
-	var driver string
-	switch {
-	case typeDB == "postgresql":
-		driver = "postgres"
-	case typeDB == "mysql":
-		driver = "mysql"
+	if role == shema.RoleViewer {
+		// наблюдатель работает только с выданными ему подключениями и только на чтение
+		if !saved.Shared {
+			return shema.Connection{}, fmt.Errorf("%w: viewers can only use connections shared with them", constants.ErrForbidden)
+		}
+		saved.ReadOnly = true
 	}
-	db, err := sql.Open(driver, connect)
+	connect, err := s.keyring.Open(saved.ConnectionString)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return "", err
+		return shema.Connection{}, fmt.Errorf("can't decrypt connection string: %w", err)
 	}
-	c.Conn = db
-	c.DBName = dbName
-	c.TypeDB = typeDB
-	s.connections[user] = append(s.connections[user], c)
-	return typeDB, nil
+	c := shema.Connection{DBName: saved.DBName, TypeDB: saved.TypeDB, ReadOnly: saved.ReadOnly, SavedID: id, TeamID: saved.TeamID}
+	registered, err := s.register(user, c, dialect.Dialect(saved.TypeDB).Driver(), connect)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		s.auditConnection(ctx, user, shema.AuditConnectionOpen, c, err)
+		return shema.Connection{}, err
+	}
+	s.auditConnection(ctx, user, shema.AuditConnectionOpen, registered, nil)
+	return registered, nil
 }
 
 func (s *Service) Registration(ctx context.Context, user, password string) error {
@@ -248,13 +415,16 @@ func (s *Service) Registration(ctx context.Context, user, password string) error
 	err = s.storage.Registration(ctx, user, hashedPassword)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") {
+			s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditRegistration, Detail: "login already exists"})
 			return constants.ErrAlreadyExists
 		} else {
 			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+			s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditRegistration, Detail: err.Error()})
 			return fmt.Errorf("not saved")
 		}
 	}
 
+	s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditRegistration, Success: true})
 	return nil
 }
 
@@ -262,35 +432,32 @@ func (s *Service) Login(ctx context.Context, user, password string) error {
 	pass, err := s.storage.Login(ctx, user)
 	if err != nil {
 		if strings.Contains(err.Error(), "user not registered") {
+			s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditLoginFailed, Detail: "unknown login"})
 			return constants.ErrInvalidData
 		} else {
+			s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditLoginFailed, Detail: err.Error()})
 			return constants.ErrInvalidData
 		}
 	}
 
 	err = bcrypt.CompareHashAndPassword(pass, []byte(password))
 	if err != nil {
+		s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditLoginFailed, Detail: "wrong password"})
 		return constants.ErrInvalidData
 	}
 
+	s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditLogin, Success: true})
 	return nil
 }
 
-func (s *Service) GetTables(ctx context.Context, user string) ([]string, error) {
+func (s *Service) GetTables(ctx context.Context, user, connID string) ([]string, error) {
 	const op = "service.GetTables"
-	var connectionString *sql.DB
-	connection, ok := s.connections[user]
-	typeDB := ""
-	for _, conn := range connection {
-		if conn.Flag {
-			typeDB = conn.TypeDB
-			connectionString = conn.Conn
-		}
-	}
-	if !ok {
-		return nil, fmt.Errorf("no connections")
+	conn, err := s.resolve(user, connID)
+	if err != nil {
+		return nil, err
 	}
-	res, err := GetAllTables(ctx, connectionString, typeDB)
+	defer s.pools.Acquire(conn.Conn)()
+	res, err := GetAllTables(ctx, conn.Conn, conn.TypeDB)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
 		return nil, fmt.Errorf("can't get tables: %w", err)
@@ -331,127 +498,120 @@ func GetAllTables(ctx context.Context, connectionString *sql.DB, dbType string)
 	return tables, nil
 }
 
-func (s *Service) QueryFromFile(ctx context.Context, file *multipart.FileHeader, user string) ([][]string, error) {
+func (s *Service) QueryFromFile(ctx context.Context, file *multipart.FileHeader, user, connID string, opts shema.ScriptOptions) (*shema.ScriptResult, error) {
 	const op = "service.QueryFromFile"
 	if file == nil {
 		return nil, fmt.Errorf("missing file")
 	}
-	var connectionString *sql.DB
-	connection, ok := s.connections[user]
-	for _, conn := range connection {
-		if conn.Flag {
-			connectionString = conn.Conn
-		}
-	}
-	if !ok {
-		return nil, fmt.Errorf("no connections")
+	conn, err := s.resolve(user, connID)
+	if err != nil {
+		return nil, err
 	}
 	f, err := file.Open()
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	fileBytes, err := io.ReadAll(f)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
 		return nil, err
 	}
-	if strings.Contains(string(fileBytes), "INSERT") || strings.Contains(string(fileBytes), "DELETE") || strings.Contains(string(fileBytes), "UPDATE") {
-		err := ExecWithoutRes(ctx, string(fileBytes), connectionString)
-		if err != nil {
-			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-			return nil, err
-		}
-		return nil, nil
-	}
 
-	res, err := ExecWithRes(ctx, string(fileBytes), connectionString)
+	script := string(fileBytes)
+	runCtx, dbConn, finish, err := s.startQuery(ctx, user, conn, shema.QueryRequest{Query: script, QueryID: opts.QueryID, Timeout: opts.Timeout})
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
 		return nil, err
 	}
-	return res, nil
-}
+	defer finish()
 
-func (s *Service) Logout(user string) error {
-	connection, ok := s.connections[user]
-	if !ok {
-		return nil
-	}
-	for i := range connection {
-		if connection[i].Flag {
-			connection[i].Flag = false
+	opts.MaxRows = s.maxRows()
+	started := time.Now()
+	res, err := ExecScript(runCtx, script, conn, dbConn, opts)
+	if err != nil {
+		s.recordHistory(ctx, user, conn, historyEntry(shema.HistorySourceScript, script, started, 0), err)
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		if errors.Is(err, constants.ErrReadOnly) {
+			return nil, err
 		}
+		return nil, queryErr(ctx, runCtx, err)
 	}
+	s.recordScript(ctx, user, conn, res)
+	return res, nil
+}
 
+func (s *Service) Logout(user string) error {
+	s.release(s.connections.RemoveAll(user)...)
 	return nil
 }
 
-func (s *Service) SaveQuery(ctx context.Context, query, user string) error {
-	const op = "service.SaveQuery"
-	t := time.Now()
-	t.Format("2006-01-02 15:04:05")
-	typeDB := ""
-	dbName := ""
-	connection, ok := s.connections[user]
-	for _, conn := range connection {
-		if conn.Flag {
-			typeDB = conn.TypeDB
-			dbName = conn.DBName
-		}
-	}
-	if !ok {
-		return fmt.Errorf("no connections")
-	}
-	err := s.storage.SaveQuery(ctx, user, typeDB, dbName, query, t)
+// Switch деактивирует подключение: оно остается открытым, но не используется, пока его снова не выберут
+func (s *Service) Switch(user, connID string) error {
+	const op = "service.Switch"
+	err := s.connections.Deactivate(user, connID)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return fmt.Errorf("can't save query")
+		return err
 	}
 	return nil
 }
 
-func (s *Service) GetHistory(ctx context.Context, user string) ([][]string, error) {
-	const op = "service.GetHistory"
-	connection, ok := s.connections[user]
-	if !ok {
-		return nil, fmt.Errorf("no connections")
-	}
-	dbName := ""
-	for _, conn := range connection {
-		if conn.Flag {
-			dbName = conn.DBName
-		}
-	}
-	res, err := s.storage.GetHistory(ctx, user, dbName)
+// GetLastDB возвращает недавно использованные подключения с замаскированными строками подключения
+func (s *Service) GetLastDB(ctx context.Context, user string) ([]shema.SavedConnection, error) {
+	const op = "service.GetLastDB"
+	conns, err := s.storage.GetLastDB(ctx, user)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return nil, fmt.Errorf("can't get history: %w", err)
-	}
-	return res, nil
-}
-
-func (s *Service) Switch(user, typeDB string) error {
-	const op = "service.Switch"
-	connection, ok := s.connections[user]
-	if !ok {
-		return fmt.Errorf("no connections")
+		return nil, fmt.Errorf("can't get last db: %w", err)
 	}
-	for i := range connection {
-		if connection[i].Flag && connection[i].TypeDB == typeDB {
-			connection[i].Flag = false
+	for i := range conns {
+		if conns[i].Shared {
+			// строку подключения чужого подключения не показываем даже замаскированной
+			conns[i].ConnectionString = ""
+			continue
+		}
+		connect, err := s.keyring.Open(conns[i].ConnectionString)
+		if err != nil {
+			s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+			connect = ""
 		}
+		conns[i].Masked = secret.MaskDSN(connect)
+		conns[i].ConnectionString = ""
 	}
-
-	return nil
+	return conns, nil
 }
 
-func (s *Service) GetLastDB(ctx context.Context, user string) (map[string]string, error) {
-	const op = "service.GetLastDB"
-	m, err := s.storage.GetLastDB(ctx, user)
+// Reencrypt перешифровывает сохраненные строки подключения активным ключом,
+// включая записи, сохраненные до включения шифрования. Возвращает число обновленных записей.
+func (s *Service) Reencrypt(ctx context.Context) (int, error) {
+	const op = "service.Reencrypt"
+	if !s.keyring.Enabled() {
+		return 0, fmt.Errorf("encryption keys are not configured")
+	}
+	conns, err := s.storage.GetAllConnectionSecrets(ctx)
 	if err != nil {
 		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return nil, fmt.Errorf("can't get last db: %w", err)
+		return 0, err
+	}
+	updated := 0
+	for _, c := range conns {
+		if !s.keyring.NeedsRotation(c.ConnectionString) {
+			continue
+		}
+		connect, err := s.keyring.Open(c.ConnectionString)
+		if err != nil {
+			return updated, fmt.Errorf("connection %d: %w", c.ID, err)
+		}
+		sealed, err := s.keyring.Seal(connect)
+		if err != nil {
+			return updated, fmt.Errorf("connection %d: %w", c.ID, err)
+		}
+		if err := s.storage.UpdateConnectionSecret(ctx, c.ID, sealed); err != nil {
+			return updated, fmt.Errorf("connection %d: %w", c.ID, err)
+		}
+		updated++
 	}
-	return m, nil
+	return updated, nil
 }
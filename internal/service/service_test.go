@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"smartTables/config"
+	"smartTables/internal/audit"
+	"smartTables/internal/constants"
+	"smartTables/internal/dialect"
+	"smartTables/internal/domains"
+	"smartTables/internal/importer"
+	"smartTables/internal/pool"
+	"smartTables/internal/registry"
+	"smartTables/internal/running"
+	"smartTables/internal/secret"
+	"smartTables/internal/shema"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testStorage подменяет Postgres: тестам нужны только роль пользователя, история и аудит
+type testStorage struct {
+	domains.Storage
+	history []shema.HistoryEntry
+}
+
+func (s *testStorage) GetUserRole(ctx context.Context, user string) (string, error) {
+	return shema.RoleEditor, nil
+}
+
+func (s *testStorage) SaveQuery(ctx context.Context, user string, e shema.HistoryEntry) error {
+	s.history = append(s.history, e)
+	return nil
+}
+
+func (s *testStorage) SaveAuditEvent(ctx context.Context, e shema.AuditEvent) error {
+	return nil
+}
+
+// newTestService возвращает сервис с открытым подключением пользователя alice к новой базе SQLite;
+// setup выполняется напрямую, в обход проверки read-only
+func newTestService(t *testing.T, readOnly bool, setup ...string) (*Service, *testStorage, shema.Connection) {
+	t.Helper()
+	keyring, err := secret.NewKeyring("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &testStorage{}
+	s := &Service{
+		storage:     st,
+		logger:      zap.NewNop(),
+		config:      config.Config{},
+		connections: registry.New(),
+		pools:       pool.New(pool.Options{}),
+		keyring:     keyring,
+		running:     running.New(),
+		imports:     importer.NewStore(importTTL),
+		audit:       audit.New(zap.NewNop(), audit.StoreSink(st)),
+	}
+	t.Cleanup(func() { s.Close() })
+
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	conn, err := s.register("alice", shema.Connection{TypeDB: string(dialect.SQLite), DBName: "test", ReadOnly: readOnly}, dialect.SQLite.Driver(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range setup {
+		if _, err := conn.Conn.Exec(q); err != nil {
+			t.Fatalf("setup %q: %v", q, err)
+		}
+	}
+	return s, st, conn
+}
+
+func TestExecQueryRowsAffected(t *testing.T) {
+	s, st, conn := newTestService(t, false,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)",
+		"INSERT INTO t (v) VALUES ('a'), ('b'), ('c')")
+
+	res, err := s.ExecQuery(context.Background(), "alice", shema.QueryRequest{Query: "UPDATE t SET v = 'x' WHERE id > 1", ConnID: conn.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Columns != nil || res.RowsAffected != 2 {
+		t.Fatalf("UPDATE: got columns %v, rowsAffected %d; want no columns and 2", res.Columns, res.RowsAffected)
+	}
+	if len(st.history) != 1 || *st.history[0].Rows != 2 {
+		t.Fatalf("history: got %+v, want one entry with 2 rows", st.history)
+	}
+
+	res, err = s.ExecQuery(context.Background(), "alice", shema.QueryRequest{Query: "SELECT v FROM t ORDER BY id", ConnID: conn.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Columns) != 1 || len(res.Rows) != 3 || res.RowsAffected != 0 {
+		t.Fatalf("SELECT: got %d columns, %d rows, rowsAffected %d", len(res.Columns), len(res.Rows), res.RowsAffected)
+	}
+}
+
+// scriptFile упаковывает скрипт в загруженный файл, как его получает QueryFromFile
+func scriptFile(t *testing.T, script string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("file", "script.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(script))
+	w.Close()
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File["file"][0]
+}
+
+func TestQueryFromFileSingleConnection(t *testing.T) {
+	s, st, conn := newTestService(t, false)
+
+	script := "CREATE TEMP TABLE tmp (v INTEGER); INSERT INTO tmp VALUES (1), (2); SELECT count(*) FROM tmp;"
+	res, err := s.QueryFromFile(context.Background(), scriptFile(t, script), "alice", conn.ID, shema.ScriptOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failed != 0 || len(res.Statements) != 3 {
+		t.Fatalf("script: got %+v, want 3 statements without errors", res)
+	}
+	if got := res.Statements[2].Result.Rows[0][0].Value; got != "2" {
+		t.Fatalf("count(*) from temp table: got %s, want 2", got)
+	}
+	if running := s.RunningQueries("alice"); len(running) != 0 {
+		t.Fatalf("running queries after script: got %v, want none", running)
+	}
+	if len(st.history) != 3 {
+		t.Fatalf("history: got %d entries, want 3", len(st.history))
+	}
+	for i, e := range st.history {
+		if !e.Time.Equal(res.Statements[i].Started) {
+			t.Fatalf("history time of statement %d: got %v, want %v", i, e.Time, res.Statements[i].Started)
+		}
+		if i > 0 && e.Time.Before(st.history[i-1].Time) {
+			t.Fatalf("history time of statement %d is before the previous one", i)
+		}
+	}
+
+	// соединение скрипта не возвращается в пул, поэтому временная таблица не видна следующим запросам
+	if _, err := s.ExecQuery(context.Background(), "alice", shema.QueryRequest{Query: "SELECT * FROM tmp", ConnID: conn.ID}); err == nil {
+		t.Fatal("temp table of the script is visible to a later query")
+	}
+}
+
+func TestReadOnlyRejectsPragmaWrites(t *testing.T) {
+	s, _, conn := newTestService(t, true)
+
+	for _, q := range []string{"PRAGMA user_version(5)", "PRAGMA user_version = 5", "PRAGMA query_only(0)"} {
+		if _, err := s.ExecQuery(context.Background(), "alice", shema.QueryRequest{Query: q, ConnID: conn.ID}); !errors.Is(err, constants.ErrReadOnly) {
+			t.Fatalf("%s: got %v, want ErrReadOnly", q, err)
+		}
+	}
+
+	res, err := s.ExecQuery(context.Background(), "alice", shema.QueryRequest{Query: "PRAGMA user_version", ConnID: conn.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Rows[0][0].Value; got != "0" {
+		t.Fatalf("user_version: got %s, want 0", got)
+	}
+}
+
+func TestTableDataLoadsOneTable(t *testing.T) {
+	s, _, conn := newTestService(t, false,
+		"CREATE TABLE t (a INTEGER, b TEXT NOT NULL, c TEXT, PRIMARY KEY (b, a))",
+		"INSERT INTO t VALUES (1, 'x', 'one'), (2, 'x', 'two')")
+
+	res, err := s.TableData(context.Background(), "alice", shema.GridRequest{ConnID: conn.ID, Table: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Table.Schema != "main" || len(res.Table.Columns) != 3 || fmt.Sprint(res.Table.PrimaryKey) != "[b a]" {
+		t.Fatalf("table: got %+v, want main.t with 3 columns and key [b a]", res.Table)
+	}
+	if len(res.Result.Rows) != 2 {
+		t.Fatalf("rows: got %d, want 2", len(res.Result.Rows))
+	}
+
+	if _, err := s.TableData(context.Background(), "alice", shema.GridRequest{ConnID: conn.ID, Schema: "other", Table: "t"}); !errors.Is(err, constants.ErrNotFound) {
+		t.Fatalf("unknown schema: got %v, want ErrNotFound", err)
+	}
+}
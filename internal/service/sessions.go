@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strconv"
+)
+
+// LoadSession возвращает серверную сессию по sha256 токена; ErrNotFound - сессии нет, она истекла или отозвана
+func (s *Service) LoadSession(ctx context.Context, id string) (shema.Session, error) {
+	const op = "service.LoadSession"
+	e, err := s.storage.LoadSession(ctx, id)
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+	}
+	return e, err
+}
+
+func (s *Service) SaveSession(ctx context.Context, e shema.Session) error {
+	const op = "service.SaveSession"
+	if err := s.storage.SaveSession(ctx, e); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	return nil
+}
+
+// DeleteSession удаляет сессию при выходе; уже удаленная сессия ошибкой не считается
+func (s *Service) DeleteSession(ctx context.Context, id string) error {
+	const op = "service.DeleteSession"
+	err := s.storage.DeleteSession(ctx, "", id)
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	return nil
+}
+
+// ListSessions возвращает активные сессии пользователя
+func (s *Service) ListSessions(ctx context.Context, user string) ([]shema.Session, error) {
+	const op = "service.ListSessions"
+	res, err := s.storage.ListSessions(ctx, user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get sessions: %w", err)
+	}
+	return res, nil
+}
+
+// RevokeSession завершает одну из сессий пользователя, например на потерянном устройстве
+func (s *Service) RevokeSession(ctx context.Context, user, id string) error {
+	const op = "service.RevokeSession"
+	if err := s.storage.DeleteSession(ctx, user, id); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: user, Action: shema.AuditSessionRevoke, Success: true, Detail: "session " + id[:min(len(id), 12)]})
+	return nil
+}
+
+// RevokeUserSessions завершает все сессии пользователя и возвращает их число
+func (s *Service) RevokeUserSessions(ctx context.Context, admin, user string) (int64, error) {
+	const op = "service.RevokeUserSessions"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return 0, err
+	}
+	n, err := s.storage.DeleteUserSessions(ctx, user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return 0, err
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditSessionRevoke, Success: true,
+		Detail: "all sessions of " + user + ": " + strconv.FormatInt(n, 10)})
+	return n, nil
+}
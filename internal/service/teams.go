@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strconv"
+	"strings"
+)
+
+// UserTeams возвращает команды пользователя - для выбора владельца нового подключения
+func (s *Service) UserTeams(ctx context.Context, user string) ([]shema.Team, error) {
+	const op = "service.UserTeams"
+	teams, err := s.storage.UserTeams(ctx, user)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get teams: %w", err)
+	}
+	return teams, nil
+}
+
+// requireTeam возвращает ErrForbidden, если пользователь не состоит в команде
+func (s *Service) requireTeam(ctx context.Context, user string, teamID int64) error {
+	teams, err := s.UserTeams(ctx, user)
+	if err != nil {
+		return err
+	}
+	for _, t := range teams {
+		if t.ID == teamID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: you are not a member of team %d", constants.ErrForbidden, teamID)
+}
+
+func (s *Service) ListTeams(ctx context.Context, admin string) ([]shema.Team, error) {
+	const op = "service.ListTeams"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return nil, err
+	}
+	teams, err := s.storage.ListTeams(ctx)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return nil, fmt.Errorf("can't get teams: %w", err)
+	}
+	return teams, nil
+}
+
+func (s *Service) CreateTeam(ctx context.Context, admin, name string) (int64, error) {
+	const op = "service.CreateTeam"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return 0, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, errors.New("team name is required")
+	}
+	id, err := s.storage.CreateTeam(ctx, name)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return 0, err
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditTeamChange, Success: true, Detail: "create " + name})
+	return id, nil
+}
+
+// DeleteTeam удаляет команду вместе с ее подключениями и закрывает их у участников
+func (s *Service) DeleteTeam(ctx context.Context, admin string, id int64) error {
+	const op = "service.DeleteTeam"
+	teams, err := s.ListTeams(ctx, admin)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(teams, func(t shema.Team) bool { return t.ID == id })
+	if i < 0 {
+		return fmt.Errorf("%w: team %d", constants.ErrNotFound, id)
+	}
+	if err := s.storage.DeleteTeam(ctx, id); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	for _, member := range teams[i].Members {
+		s.closeWhere(member, func(c shema.Connection) bool { return c.TeamID == id })
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditTeamChange, Success: true, Detail: "delete " + teams[i].Name})
+	return nil
+}
+
+func (s *Service) AddTeamMember(ctx context.Context, admin string, m shema.TeamMember) error {
+	const op = "service.AddTeamMember"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return err
+	}
+	m.Login = strings.TrimSpace(m.Login)
+	if err := s.storage.AddTeamMember(ctx, m); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditTeamChange, Success: true,
+		Detail: "add " + m.Login + " to team " + strconv.FormatInt(m.TeamID, 10)})
+	return nil
+}
+
+// RemoveTeamMember исключает пользователя из команды и закрывает открытые им подключения команды
+func (s *Service) RemoveTeamMember(ctx context.Context, admin string, m shema.TeamMember) error {
+	const op = "service.RemoveTeamMember"
+	if err := s.requireRole(ctx, admin, shema.RoleAdmin); err != nil {
+		return err
+	}
+	if err := s.storage.RemoveTeamMember(ctx, m); err != nil {
+		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
+		return err
+	}
+	s.closeWhere(m.Login, func(c shema.Connection) bool { return c.TeamID == m.TeamID })
+	s.audit.Record(ctx, shema.AuditEvent{Actor: admin, Action: shema.AuditTeamChange, Success: true,
+		Detail: "remove " + m.Login + " from team " + strconv.FormatInt(m.TeamID, 10)})
+	return nil
+}
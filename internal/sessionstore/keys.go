@@ -0,0 +1,29 @@
+package sessionstore
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const minKeyLen = 32
+
+// KeyPairs превращает ключи сессий из конфига (base64, не короче 32 байт) в пары ключей
+// подписи и шифрования cookie. Первый ключ подписывает новые cookie, остальные только
+// проверяют старые - так ключ меняют без выхода всех пользователей.
+func KeyPairs(keys []string) ([][]byte, error) {
+	pairs := make([][]byte, 0, 2*len(keys))
+	for i, k := range keys {
+		key, err := base64.StdEncoding.DecodeString(k)
+		if err != nil {
+			return nil, fmt.Errorf("session key %d: %v", i, err)
+		}
+		if len(key) < minKeyLen {
+			return nil, fmt.Errorf("session key %d: must be at least %d bytes, got %d", i, minKeyLen, len(key))
+		}
+		// ключ шифрования выводим из того же секрета, чтобы в конфиге хватало одного значения
+		block := sha256.Sum256(append([]byte("smartTables session encryption:"), key...))
+		pairs = append(pairs, key, block[:])
+	}
+	return pairs, nil
+}
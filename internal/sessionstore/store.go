@@ -0,0 +1,200 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"net/http"
+	"smartTables/internal/clientip"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"time"
+)
+
+// RenewKey - если значение с этим ключом есть в сессии, Save выдает ей новый токен и удаляет прежнюю запись.
+// Его ставят при входе, чтобы сессия, известная кому-то до входа, не стала сессией пользователя.
+const RenewKey = "_renew"
+
+// Backend хранит сессии; ID в нем - sha256 токена из cookie
+type Backend interface {
+	LoadSession(ctx context.Context, id string) (shema.Session, error)
+	SaveSession(ctx context.Context, e shema.Session) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+// Store - хранилище сессий gin на сервере: в cookie лежит только подписанный случайный
+// токен, значения сессии хранятся в Backend. Поэтому сессию можно отозвать, а несколько
+// экземпляров приложения с общей базой видят одни и те же сессии.
+// Сессии без входа в Backend не попадают, их значения хранятся в cookie.
+type Store struct {
+	backend Backend
+	codecs  []securecookie.Codec
+	options *gsessions.Options
+}
+
+// NewStore создает хранилище; keyPairs - ключи подписи cookie, как у cookie.NewStore
+func NewStore(backend Backend, keyPairs ...[]byte) *Store {
+	return &Store{
+		backend: backend,
+		codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		options: &gsessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+}
+
+// ID возвращает ID сессии в хранилище по токену из sessions.Session.ID()
+func ID(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New загружает сессию по cookie. Неподписанный, истекший или отозванный токен дает новую пустую сессию.
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var token string
+	if err := securecookie.DecodeMulti(name, c.Value, &token, s.codecs...); err != nil {
+		// анонимная сессия хранится в самой cookie; вход из cookie не принимаем - его нельзя отозвать
+		err := securecookie.DecodeMulti(name, c.Value, &session.Values, s.codecs...)
+		if err != nil || signedIn(session.Values) {
+			session.Values = make(map[interface{}]interface{})
+			return session, nil
+		}
+		session.IsNew = false
+		return session, nil
+	}
+	e, err := s.backend.LoadSession(r.Context(), ID(token))
+	if err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			return session, nil
+		}
+		return session, err
+	}
+	if err := (securecookie.GobEncoder{}).Deserialize(e.Data, &session.Values); err != nil {
+		return session, err
+	}
+	session.ID = token
+	session.IsNew = false
+	return session, nil
+}
+
+// Save сохраняет сессию. Пустая сессия (после Clear) или MaxAge < 0 удаляет ее вместе с cookie.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	ctx := r.Context()
+	if _, ok := session.Values[RenewKey]; ok {
+		delete(session.Values, RenewKey)
+		if session.ID != "" {
+			if err := s.backend.DeleteSession(ctx, ID(session.ID)); err != nil {
+				return err
+			}
+			session.ID = ""
+		}
+	}
+	if session.Options.MaxAge < 0 || len(session.Values) == 0 {
+		if session.ID != "" {
+			if err := s.backend.DeleteSession(ctx, ID(session.ID)); err != nil {
+				return err
+			}
+		}
+		opts := *session.Options
+		opts.MaxAge = -1
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", &opts))
+		return nil
+	}
+
+	// логин пишем отдельной колонкой, чтобы показывать и отзывать сессии пользователя
+	login, _ := session.Values["login"].(string)
+	if !signedIn(session.Values) {
+		return s.saveAnonymous(r, w, session)
+	}
+
+	if session.ID == "" {
+		token, err := newToken()
+		if err != nil {
+			return err
+		}
+		session.ID = token
+	}
+	e := shema.Session{ID: ID(session.ID), Login: login, ClientIP: clientip.From(ctx), UserAgent: r.UserAgent()}
+	maxAge := session.Options.MaxAge
+	if maxAge == 0 {
+		maxAge = s.options.MaxAge
+	}
+	e.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+
+	data, err := (securecookie.GobEncoder{}).Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+	e.Data = data
+	if err := s.backend.SaveSession(ctx, e); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// signedInKeys - значения, которые бывают только у сессии после входа
+var signedInKeys = []string{"login", "authenticated"}
+
+func signedIn(values map[interface{}]interface{}) bool {
+	for _, key := range signedInKeys {
+		if _, ok := values[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// saveAnonymous кладет значения сессии без входа (CSRF-токен страницы входа) в подписанную cookie,
+// чтобы каждый посетитель не оставлял строку в базе. Серверная запись, если была, удаляется.
+func (s *Store) saveAnonymous(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.ID != "" {
+		if err := s.backend.DeleteSession(r.Context(), ID(session.ID)); err != nil {
+			return err
+		}
+		session.ID = ""
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.Values, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,116 @@
+package sessionstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+)
+
+// memBackend хранит сессии в памяти вместо таблицы sessions
+type memBackend map[string]shema.Session
+
+func (b memBackend) LoadSession(ctx context.Context, id string) (shema.Session, error) {
+	e, ok := b[id]
+	if !ok {
+		return e, constants.ErrNotFound
+	}
+	return e, nil
+}
+
+func (b memBackend) SaveSession(ctx context.Context, e shema.Session) error {
+	b[e.ID] = e
+	return nil
+}
+
+func (b memBackend) DeleteSession(ctx context.Context, id string) error {
+	delete(b, id)
+	return nil
+}
+
+// roundTrip сохраняет значения в сессию запроса r и возвращает запрос с полученной cookie
+func roundTrip(t *testing.T, s *Store, r *http.Request, values map[string]interface{}) *http.Request {
+	t.Helper()
+	session, err := s.New(r, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range values {
+		session.Values[k] = v
+	}
+	w := httptest.NewRecorder()
+	if err := s.Save(r, w, session); err != nil {
+		t.Fatal(err)
+	}
+	next := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		next.AddCookie(c)
+	}
+	return next
+}
+
+func TestAnonymousSessionStaysInCookie(t *testing.T) {
+	backend := memBackend{}
+	s := NewStore(backend, []byte("0123456789abcdef0123456789abcdef"))
+
+	r := roundTrip(t, s, httptest.NewRequest(http.MethodGet, "/", nil), map[string]interface{}{"csrf": "x"})
+	if len(backend) != 0 {
+		t.Fatalf("anonymous session was stored in the backend: %v", backend)
+	}
+	session, err := s.New(r, "token")
+	if err != nil || session.Values["csrf"] != "x" || session.IsNew {
+		t.Fatalf("anonymous session: got %v, new %v, %v; want csrf x from cookie", session.Values, session.IsNew, err)
+	}
+
+	r = roundTrip(t, s, r, map[string]interface{}{"login": "alice"})
+	if len(backend) != 1 {
+		t.Fatalf("signed-in session: got %d backend rows, want 1", len(backend))
+	}
+	session, err = s.New(r, "token")
+	if err != nil || session.Values["login"] != "alice" || session.Values["csrf"] != "x" {
+		t.Fatalf("signed-in session: got %v, %v", session.Values, err)
+	}
+}
+
+func TestRenewIssuesNewToken(t *testing.T) {
+	backend := memBackend{}
+	s := NewStore(backend, []byte("0123456789abcdef0123456789abcdef"))
+
+	r := roundTrip(t, s, httptest.NewRequest(http.MethodGet, "/", nil), map[string]interface{}{"login": "alice"})
+	before, _ := s.New(r, "token")
+
+	r = roundTrip(t, s, r, map[string]interface{}{RenewKey: true})
+	after, _ := s.New(r, "token")
+	if after.ID == "" || after.ID == before.ID {
+		t.Fatalf("renew: got token %q, want a new one instead of %q", after.ID, before.ID)
+	}
+	if _, ok := backend[ID(before.ID)]; ok || len(backend) != 1 {
+		t.Fatalf("renew: old row is still stored, backend has %d rows", len(backend))
+	}
+	if _, ok := after.Values[RenewKey]; ok || after.Values["login"] != "alice" {
+		t.Fatalf("renew: got values %v, want login without the renew flag", after.Values)
+	}
+}
+
+func TestSignedInCookieIsRejected(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	s := NewStore(memBackend{}, key)
+
+	// cookie прежнего хранилища с теми же ключами: значения сессии лежат в ней целиком
+	values := map[interface{}]interface{}{"authenticated": true, "login": "alice", "csrf": "x"}
+	encoded, err := securecookie.EncodeMulti("token", values, securecookie.CodecsFromPairs(key)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "token", Value: encoded})
+
+	session, err := s.New(r, "token")
+	if err != nil || len(session.Values) != 0 || !session.IsNew {
+		t.Fatalf("signed-in cookie: got %v, new %v, %v; want an empty new session", session.Values, session.IsNew, err)
+	}
+}
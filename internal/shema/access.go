@@ -0,0 +1,54 @@
+package shema
+
+// Роли пользователей: viewer только читает на выданных ему подключениях,
+// editor создает свои подключения и меняет данные, admin вдобавок управляет пользователями и доступами
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Roles - роли от старшей к младшей
+var Roles = []string{RoleAdmin, RoleEditor, RoleViewer}
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// RoleAllows сообщает, не ниже ли role, чем min. Неизвестная роль не дает ничего.
+func RoleAllows(role, min string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[min]
+}
+
+type User struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// ConnectionAccess - сохраненное подключение и пользователи, которым оно выдано
+type ConnectionAccess struct {
+	ID       int64    `json:"id"`
+	Owner    string   `json:"owner"`
+	TypeDB   string   `json:"typeDB"`
+	DBName   string   `json:"dbName"`
+	ReadOnly bool     `json:"readOnly"`
+	Team     string   `json:"team,omitempty"`
+	Grants   []string `json:"grants"`
+}
+
+// ConnectionGrant - доступ пользователя Login к сохраненному подключению ConnectionID
+type ConnectionGrant struct {
+	ConnectionID int64  `json:"connectionId" form:"connectionId" binding:"required"`
+	Login        string `json:"login" form:"login" binding:"required"`
+}
+
+// Team - команда, которой принадлежат общие подключения. Участники пользуются ими, не видя строки подключения.
+type Team struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name" binding:"required"`
+	Members []string `json:"members"`
+}
+
+// TeamMember - участник Login команды TeamID
+type TeamMember struct {
+	TeamID int64  `json:"teamId" form:"teamId" binding:"required"`
+	Login  string `json:"login" form:"login" binding:"required"`
+}
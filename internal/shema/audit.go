@@ -0,0 +1,60 @@
+package shema
+
+import "time"
+
+// События журнала аудита
+const (
+	AuditLogin            = "login"
+	AuditLoginFailed      = "login_failed"
+	AuditRegistration     = "registration"
+	AuditConnectionCreate = "connection_create"
+	AuditConnectionOpen   = "connection_open"
+	AuditQuery            = "query"
+	AuditExport           = "export"
+	AuditRoleChange       = "role_change"
+	AuditGrant            = "grant"
+	AuditRevoke           = "revoke"
+	AuditTeamChange       = "team_change"
+	AuditSessionRevoke    = "session_revoke"
+)
+
+// AuditActions - события в порядке показа в фильтре
+var AuditActions = []string{AuditLogin, AuditLoginFailed, AuditRegistration, AuditConnectionCreate, AuditConnectionOpen, AuditQuery, AuditExport,
+	AuditRoleChange, AuditGrant, AuditRevoke, AuditTeamChange, AuditSessionRevoke}
+
+// AuditEvent - запись журнала аудита. Текст запроса не хранится, только его хеш:
+// сам текст есть в истории пользователя.
+// ConnectionID - ID сохраненного подключения, 0 для подключений к загруженным файлам.
+type AuditEvent struct {
+	ID           int64     `json:"id,omitempty"`
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	Success      bool      `json:"success"`
+	ConnectionID int64     `json:"connectionId,omitempty"`
+	TypeDB       string    `json:"typeDB,omitempty"`
+	DBName       string    `json:"dbName,omitempty"`
+	QueryHash    string    `json:"queryHash,omitempty"`
+	ClientIP     string    `json:"clientIp,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+}
+
+// AuditFilter - поиск по журналу; даты в формате YYYY-MM-DD, обе включительно
+type AuditFilter struct {
+	Actor     string `json:"actor" form:"actor"`
+	Action    string `json:"action" form:"action"`
+	DBName    string `json:"dbName" form:"dbName"`
+	QueryHash string `json:"queryHash" form:"queryHash"`
+	From      string `json:"from" form:"from"`
+	To        string `json:"to" form:"to"`
+	Offset    int    `json:"offset" form:"offset"`
+	Limit     int    `json:"limit" form:"limit"`
+}
+
+// AuditPage - страница журнала, новые события первыми
+type AuditPage struct {
+	Events  []AuditEvent `json:"events"`
+	Offset  int          `json:"offset"`
+	Limit   int          `json:"limit"`
+	HasMore bool         `json:"hasMore"`
+}
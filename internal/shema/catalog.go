@@ -0,0 +1,48 @@
+package shema
+
+// DBSchema - схема базы (в MySQL - база данных) со своими таблицами
+type DBSchema struct {
+	Name   string  `json:"name"`
+	Tables []Table `json:"tables"`
+}
+
+const (
+	TableKindTable            = "table"
+	TableKindView             = "view"
+	TableKindMaterializedView = "materialized view"
+)
+
+type Table struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	// Kind - table, view или materialized view
+	Kind string `json:"kind"`
+	// RowEstimate - оценка числа строк по статистике базы, -1 если неизвестно
+	RowEstimate int64         `json:"rowEstimate"`
+	Columns     []TableColumn `json:"columns"`
+	PrimaryKey  []string      `json:"primaryKey,omitempty"`
+	ForeignKeys []ForeignKey  `json:"foreignKeys,omitempty"`
+	Indexes     []Index       `json:"indexes,omitempty"`
+}
+
+type TableColumn struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+}
+
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefSchema  string   `json:"refSchema"`
+	RefTable   string   `json:"refTable"`
+	RefColumns []string `json:"refColumns"`
+}
+
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
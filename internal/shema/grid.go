@@ -0,0 +1,68 @@
+package shema
+
+// Операции фильтра в гриде таблицы
+const (
+	FilterEq      = "="
+	FilterNe      = "!="
+	FilterLt      = "<"
+	FilterLe      = "<="
+	FilterGt      = ">"
+	FilterGe      = ">="
+	FilterLike    = "like"
+	FilterNull    = "null"
+	FilterNotNull = "not null"
+)
+
+// FilterOps - операции фильтра в порядке показа в форме
+var FilterOps = []string{FilterEq, FilterNe, FilterLt, FilterLe, FilterGt, FilterGe, FilterLike, FilterNull, FilterNotNull}
+
+type GridFilter struct {
+	Column string `json:"column"`
+	Op     string `json:"op"`
+	Value  string `json:"value"`
+}
+
+// GridRequest - страница данных одной таблицы
+type GridRequest struct {
+	ConnID  string       `json:"connectionId" form:"connection"`
+	Schema  string       `json:"schema" form:"schema"`
+	Table   string       `json:"table" form:"table" binding:"required"`
+	Offset  int          `json:"offset" form:"offset"`
+	Limit   int          `json:"limit" form:"limit"`
+	Sort    string       `json:"sort" form:"sort"`
+	Desc    bool         `json:"desc" form:"desc"`
+	Filters []GridFilter `json:"filters" form:"-"`
+}
+
+type GridResult struct {
+	Table    Table        `json:"table"`
+	ReadOnly bool         `json:"readOnly"`
+	Result   *QueryResult `json:"result"`
+	// Query - построенный сервером запрос, Args - его параметры
+	Query string        `json:"query"`
+	Args  []interface{} `json:"args"`
+}
+
+// Действия над строкой таблицы
+const (
+	EditUpdate = "update"
+	EditInsert = "insert"
+	EditDelete = "delete"
+)
+
+// RowEdit - изменение одной строки; nil в Key и Values означает NULL.
+// Для update и delete строка ищется по первичному ключу из Key.
+type RowEdit struct {
+	ConnID string             `json:"connectionId"`
+	Schema string             `json:"schema"`
+	Table  string             `json:"table" binding:"required"`
+	Action string             `json:"action" binding:"required"`
+	Key    map[string]*string `json:"key"`
+	Values map[string]*string `json:"values"`
+}
+
+// EditStatement - параметризованный запрос, который выполнит RowEdit
+type EditStatement struct {
+	Query string        `json:"query"`
+	Args  []interface{} `json:"args"`
+}
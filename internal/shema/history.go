@@ -0,0 +1,68 @@
+package shema
+
+import "time"
+
+// Статусы выполнения запроса в истории
+const (
+	HistoryOK       = "ok"
+	HistoryError    = "error"
+	HistoryTimeout  = "timeout"
+	HistoryCanceled = "canceled"
+)
+
+// Откуда запущено выполнение
+const (
+	HistorySourceQuery  = "query"
+	HistorySourceScript = "script"
+	HistorySourceGrid   = "grid"
+	HistorySourceEdit   = "edit"
+	HistorySourceExport = "export"
+	HistorySourceImport = "import"
+)
+
+// HistoryStatuses - статусы в порядке показа в фильтре
+var HistoryStatuses = []string{HistoryOK, HistoryError, HistoryTimeout, HistoryCanceled}
+
+// HistoryEntry - одно выполнение запроса.
+// Rows - число возвращенных строк для выборки или затронутых строк для остальных операторов.
+// DurationMs и Rows пусты у записей, сделанных до появления метрик.
+type HistoryEntry struct {
+	ID         int64     `json:"id"`
+	TypeDB     string    `json:"typeDB"`
+	DBName     string    `json:"dbName"`
+	Query      string    `json:"query"`
+	Time       time.Time `json:"time"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs *int64    `json:"durationMs"`
+	Rows       *int64    `json:"rows"`
+	Source     string    `json:"source"`
+	ClientIP   string    `json:"clientIp"`
+}
+
+// HistoryFilter - поиск по тексту запроса, датам (YYYY-MM-DD, обе включительно), подключению и статусу.
+// Подключение задается типом и именем базы: одно имя может быть у баз разных типов.
+type HistoryFilter struct {
+	Search string `json:"search" form:"search"`
+	From   string `json:"from" form:"from"`
+	To     string `json:"to" form:"to"`
+	TypeDB string `json:"typeDB" form:"typeDB"`
+	DBName string `json:"dbName" form:"dbName"`
+	Status string `json:"status" form:"status"`
+	Offset int    `json:"offset" form:"offset"`
+	Limit  int    `json:"limit" form:"limit"`
+}
+
+// HistoryDatabase - подключение, которое встречается в истории
+type HistoryDatabase struct {
+	TypeDB string `json:"typeDB"`
+	DBName string `json:"dbName"`
+}
+
+// HistoryPage - страница истории, новые записи первыми
+type HistoryPage struct {
+	Entries []HistoryEntry `json:"entries"`
+	Offset  int            `json:"offset"`
+	Limit   int            `json:"limit"`
+	HasMore bool           `json:"hasMore"`
+}
@@ -0,0 +1,56 @@
+package shema
+
+// ImportOptions - параметры разбора загруженного файла
+type ImportOptions struct {
+	// Format - csv, tsv или xlsx; если пусто, определяется по расширению файла
+	Format    string `json:"format" form:"format"`
+	Delimiter string `json:"delimiter" form:"delimiter"`
+	// NoHeader - в первой строке данные, а не имена колонок
+	NoHeader bool   `json:"noHeader" form:"noHeader"`
+	Table    string `json:"table" form:"table" binding:"required"`
+	// Append - дописать в существующую таблицу вместо создания новой
+	Append bool `json:"append" form:"append"`
+	// ConnID - ID подключения; если пусто, берется последнее активное
+	ConnID string `json:"connectionId" form:"connection"`
+}
+
+// ImportColumn связывает колонку файла с колонкой таблицы
+type ImportColumn struct {
+	// Source - номер колонки в файле, с 0
+	Source int    `json:"source"`
+	Name   string `json:"name"`
+	// Type - один из типов импорта: integer, float, boolean, date, timestamp, text
+	Type string `json:"type"`
+}
+
+// ImportPreview - первые строки файла и предложенное сопоставление колонок
+type ImportPreview struct {
+	Token   string         `json:"token"`
+	Table   string         `json:"table"`
+	Append  bool           `json:"append"`
+	ConnID  string         `json:"connectionId"`
+	Header  []string       `json:"header"`
+	Columns []ImportColumn `json:"columns"`
+	Rows    [][]string     `json:"rows"`
+	// Targets - колонки существующей таблицы при дописывании
+	Targets []string `json:"targets,omitempty"`
+	Types   []string `json:"types"`
+}
+
+// ImportRequest - подтвержденный импорт ранее загруженного файла
+type ImportRequest struct {
+	Token   string         `json:"token" binding:"required"`
+	Table   string         `json:"table" binding:"required"`
+	Append  bool           `json:"append"`
+	ConnID  string         `json:"connectionId"`
+	Columns []ImportColumn `json:"columns" binding:"required"`
+	// Timeout в секундах; 0 - таймаут из конфига
+	Timeout int `json:"timeout"`
+}
+
+type ImportResult struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	// Method - copy, load data или insert
+	Method string `json:"method"`
+}
@@ -0,0 +1,92 @@
+package shema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Column struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType"`
+	Nullable     bool   `json:"nullable"`
+	// NullableKnown равен false, если драйвер не сообщает nullability
+	NullableKnown bool `json:"nullableKnown"`
+}
+
+// Cell хранит строковое представление значения и исходное значение драйвера
+type Cell struct {
+	Value string
+	Raw   interface{}
+	Null  bool
+}
+
+// MarshalJSON отдает NULL как null, числа, bool и время - в своих типах, остальное строкой
+func (c Cell) MarshalJSON() ([]byte, error) {
+	if c.Null {
+		return []byte("null"), nil
+	}
+	switch c.Raw.(type) {
+	case int64, float64, bool, time.Time:
+		return json.Marshal(c.Raw)
+	}
+	return json.Marshal(c.Value)
+}
+
+type QueryResult struct {
+	Columns []Column `json:"columns"`
+	Rows    [][]Cell `json:"rows"`
+	// Offset и Limit - окно строк, которое вернул запрос
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	// Truncated - после последней возвращенной строки есть еще строки
+	Truncated bool `json:"truncated"`
+	// Pageable - запрос только читает, и его можно перезапустить с другим сдвигом
+	Pageable bool `json:"pageable"`
+	// RowsAffected - число измененных строк у операторов без результата; у них Columns равен nil
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// Page - окно строк результата, Limit 0 - без ограничения
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+type ScriptOptions struct {
+	// ContinueOnError - выполнять следующие операторы после ошибки
+	ContinueOnError bool `json:"continueOnError" form:"continueOnError"`
+	// Transaction - выполнить весь скрипт в одной транзакции
+	Transaction bool `json:"transaction" form:"transaction"`
+	// QueryID - ID для отмены скрипта; если пусто, генерируется
+	QueryID string `json:"queryId" form:"queryId"`
+	// Timeout в секундах на весь скрипт; 0 - таймаут из конфига
+	Timeout int `json:"timeout" form:"timeout"`
+	// MaxRows - сколько строк результата оставлять у каждого оператора, задается сервером
+	MaxRows int `json:"-" form:"-"`
+}
+
+type ExportOptions struct {
+	// Format - csv, tsv, jsonl или xlsx
+	Format string `json:"format" form:"format"`
+	// Delimiter - разделитель полей для csv и tsv
+	Delimiter string `json:"delimiter" form:"delimiter"`
+	// Null - чем заменить NULL в csv, tsv и xlsx
+	Null string `json:"null" form:"null"`
+}
+
+type StatementResult struct {
+	Query        string       `json:"query"`
+	Kind         string       `json:"kind"`
+	Result       *QueryResult `json:"result,omitempty"`
+	RowsAffected int64        `json:"rowsAffected"`
+	Error        string       `json:"error,omitempty"`
+	Started      time.Time    `json:"startedAt"`
+	DurationMs   int64        `json:"durationMs"`
+}
+
+type ScriptResult struct {
+	Statements []StatementResult `json:"statements"`
+	// Failed - количество операторов, завершившихся ошибкой
+	Failed     int  `json:"failed"`
+	RolledBack bool `json:"rolledBack"`
+}
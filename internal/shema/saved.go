@@ -0,0 +1,27 @@
+package shema
+
+import "time"
+
+// SavedQuery - именованный запрос из библиотеки пользователя.
+// TypeDB - тип базы, для которой написан запрос, пустой - для любой.
+// Params - параметры запроса со значениями по умолчанию.
+type SavedQuery struct {
+	ID          int64        `json:"id"`
+	Owner       string       `json:"owner"`
+	Name        string       `json:"name" binding:"required"`
+	Description string       `json:"description"`
+	Query       string       `json:"query" binding:"required"`
+	TypeDB      string       `json:"typeDB"`
+	Tags        []string     `json:"tags"`
+	Params      []QueryParam `json:"params"`
+	// SharedWith - пользователи, которым запрос виден; менять его может только владелец
+	SharedWith []string  `json:"sharedWith"`
+	Created    time.Time `json:"createdAt"`
+	Updated    time.Time `json:"updatedAt"`
+}
+
+// SavedQueryFilter - поиск по имени, описанию и тексту запроса и отбор по тегу
+type SavedQueryFilter struct {
+	Search string `json:"search" form:"search"`
+	Tag    string `json:"tag" form:"tag"`
+}
@@ -0,0 +1,17 @@
+package shema
+
+import "time"
+
+// Session - серверная сессия. ID - sha256 токена из cookie: по нему сессию можно
+// показать и отозвать, но нельзя подставить в cookie.
+type Session struct {
+	ID        string    `json:"id"`
+	Login     string    `json:"login,omitempty"`
+	ClientIP  string    `json:"clientIp,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+	Expires   time.Time `json:"expires"`
+	// Data - сериализованные значения сессии
+	Data []byte `json:"-"`
+}
@@ -1,10 +1,75 @@
 package shema
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type Connection struct {
-	TypeDB string
-	DBName string
-	Conn   *sql.DB
-	Flag   bool
+	ID     string  `json:"id"`
+	TypeDB string  `json:"typeDB"`
+	DBName string  `json:"dbName"`
+	Conn   *sql.DB `json:"-"`
+	Flag   bool    `json:"active"`
+	// ReadOnly - на подключении разрешены только читающие операторы
+	ReadOnly bool `json:"readOnly"`
+	// SavedID - ID в таблице connections, 0 для подключений к загруженным файлам
+	SavedID int64 `json:"savedId,omitempty"`
+	// TeamID - команда, которой принадлежит подключение, 0 для личных
+	TeamID int64 `json:"teamId,omitempty"`
+}
+
+// QueryRequest - запрос пользователя к выбранному подключению
+type QueryRequest struct {
+	Query string `json:"query" form:"query" binding:"required"`
+	// ConnID - ID подключения; если пусто, берется последнее активное
+	ConnID string `json:"connectionId" form:"connection"`
+	// QueryID - ID для отмены запроса; если пусто, генерируется
+	QueryID string `json:"queryId" form:"queryId"`
+	// Timeout в секундах; 0 - таймаут из конфига
+	Timeout int `json:"timeout" form:"timeout"`
+	// Offset и Limit - страница результата; Limit 0 или больше лимита из конфига - лимит из конфига
+	Offset int `json:"offset" form:"offset"`
+	Limit  int `json:"limit" form:"limit"`
+	// Params - значения параметров :name и {{name}} из текста запроса
+	Params []QueryParam `json:"params" form:"-"`
+}
+
+// QueryParam - значение именованного параметра запроса. Type - подсказка типа
+// (integer, float, boolean, date, timestamp, text), по ней значение приводится перед передачей драйверу.
+type QueryParam struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Null  bool   `json:"null"`
+}
+
+// RunningQuery - выполняющийся запрос пользователя
+type RunningQuery struct {
+	ID       string     `json:"id"`
+	ConnID   string     `json:"connectionId"`
+	DBName   string     `json:"dbName"`
+	TypeDB   string     `json:"typeDB"`
+	Query    string     `json:"query"`
+	Started  time.Time  `json:"startedAt"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// BackendPID - pid процесса Postgres, выполняющего запрос
+	BackendPID int64   `json:"backendPid,omitempty"`
+	Conn       *sql.DB `json:"-"`
+}
+
+// SavedConnection - подключение из таблицы connections.
+// ConnectionString в UI не отдается, вместо него - Masked.
+type SavedConnection struct {
+	ID               int64  `json:"id"`
+	TypeDB           string `json:"typeDB"`
+	DBName           string `json:"dbName"`
+	Masked           string `json:"masked"`
+	ReadOnly         bool   `json:"readOnly"`
+	ConnectionString string `json:"-"`
+	// Shared - подключение команды или другого пользователя, выданное администратором;
+	// строка подключения у таких не показывается
+	Shared bool   `json:"shared"`
+	TeamID int64  `json:"teamId,omitempty"`
+	Team   string `json:"team,omitempty"`
 }
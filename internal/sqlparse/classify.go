@@ -0,0 +1,260 @@
+package sqlparse
+
+import "smartTables/internal/dialect"
+
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindRead
+	KindWrite
+	KindDDL
+	KindTransaction
+	KindOther
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindRead:
+		return "read"
+	case KindWrite:
+		return "write"
+	case KindDDL:
+		return "ddl"
+	case KindTransaction:
+		return "transaction"
+	case KindOther:
+		return "other"
+	}
+	return "unknown"
+}
+
+type Statement struct {
+	Kind        Kind
+	ReturnsRows bool
+	// Keyword - ведущее ключевое слово оператора (SELECT, INSERT, ...)
+	Keyword string
+}
+
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"COMMENT": true, "GRANT": true, "REVOKE": true, "REINDEX": true, "CLUSTER": true,
+	"VACUUM": true, "ANALYZE": true, "REFRESH": true, "SECURITY": true, "IMPORT": true,
+}
+
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "REPLACE": true,
+	"UPSERT": true, "COPY": true, "LOAD": true, "CALL": true, "DO": true,
+}
+
+var readKeywords = map[string]bool{
+	"SELECT": true, "VALUES": true, "TABLE": true, "SHOW": true, "DESCRIBE": true, "DESC": true,
+}
+
+// readPragmas - PRAGMA SQLite, которые с аргументом в скобках только читают
+var readPragmas = map[string]bool{
+	"TABLE_INFO": true, "TABLE_XINFO": true, "TABLE_LIST": true, "INDEX_INFO": true, "INDEX_XINFO": true,
+	"INDEX_LIST": true, "FOREIGN_KEY_LIST": true, "FOREIGN_KEY_CHECK": true, "INTEGRITY_CHECK": true,
+	"QUICK_CHECK": true, "FUNCTION_LIST": true, "MODULE_LIST": true, "PRAGMA_LIST": true, "COLLATION_LIST": true,
+	"DATABASE_LIST": true,
+}
+
+var transactionKeywords = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true,
+	"RELEASE": true, "END": true, "ABORT": true,
+}
+
+// Classify определяет тип первого оператора в тексте
+func Classify(src string, d dialect.Dialect) Statement {
+	return classifyTokens(significant(Tokenize(src, d)))
+}
+
+// significant отбрасывает пробелы и комментарии и обрезает текст по первой ';' верхнего уровня
+func significant(tokens []Token) []Token {
+	res := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Kind == Space || t.Kind == Comment {
+			continue
+		}
+		if t.Kind == Punct && t.Text == ";" {
+			if len(res) == 0 {
+				continue
+			}
+			break
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+func classifyTokens(tokens []Token) Statement {
+	// (SELECT ...) UNION (SELECT ...)
+	for len(tokens) > 0 && tokens[0].Kind == Punct && tokens[0].Text == "(" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return Statement{}
+	}
+
+	kw := tokens[0].Upper()
+	st := Statement{Keyword: kw}
+	switch {
+	case kw == "WITH":
+		return classifyWith(tokens)
+	case kw == "EXPLAIN":
+		return classifyExplain(tokens)
+	case kw == "SELECT":
+		st.Kind, st.ReturnsRows = KindRead, true
+		if hasTopLevel(tokens[1:], "INTO") {
+			// SELECT ... INTO создает таблицу или пишет в переменные
+			st.Kind, st.ReturnsRows = KindWrite, false
+		}
+	case readKeywords[kw]:
+		st.Kind, st.ReturnsRows = KindRead, true
+	case writeKeywords[kw]:
+		st.Kind = KindWrite
+		st.ReturnsRows = kw == "CALL" || hasTopLevel(tokens[1:], "RETURNING")
+	case ddlKeywords[kw]:
+		st.Kind = KindDDL
+	case transactionKeywords[kw]:
+		st.Kind = KindTransaction
+	case kw == "SET":
+		st.Kind = KindOther
+		if len(tokens) > 1 && tokens[1].Upper() == "TRANSACTION" {
+			st.Kind = KindTransaction
+		}
+	case kw == "PRAGMA":
+		// PRAGMA x = v и PRAGMA x(v) меняют настройку; читает только PRAGMA x и табличные PRAGMA вроде table_info(t)
+		st.Kind, st.ReturnsRows = KindRead, true
+		if hasPunct(tokens, "=") || (hasPunct(tokens, "(") && !readPragmas[pragmaName(tokens)]) {
+			st.Kind, st.ReturnsRows = KindOther, false
+		}
+	case kw != "":
+		st.Kind, st.ReturnsRows = KindOther, true
+	default:
+		st.ReturnsRows = true
+	}
+	return st
+}
+
+// classifyWith ищет основной оператор после списка CTE и проверяет, не изменяют ли данные сами CTE
+func classifyWith(tokens []Token) Statement {
+	st := Statement{Keyword: "WITH"}
+	modifying := false
+	depth := 0
+	for i, t := range tokens[1:] {
+		if t.Kind == Punct {
+			switch t.Text {
+			case "(":
+				depth++
+				if next := nextWord(tokens[i+2:]); writeKeywords[next] {
+					modifying = true
+				}
+			case ")":
+				depth--
+			}
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		kw := t.Upper()
+		if kw == "SELECT" || kw == "VALUES" || kw == "TABLE" || writeKeywords[kw] {
+			main := classifyTokens(tokens[i+1:])
+			main.Keyword = "WITH"
+			if modifying && main.Kind == KindRead {
+				main.Kind = KindWrite
+			}
+			return main
+		}
+	}
+	st.ReturnsRows = true
+	return st
+}
+
+// classifyExplain: EXPLAIN ANALYZE выполняет оператор, поэтому берем тип внутреннего оператора
+func classifyExplain(tokens []Token) Statement {
+	st := Statement{Kind: KindRead, ReturnsRows: true, Keyword: "EXPLAIN"}
+	analyze := false
+	rest := tokens[1:]
+	for len(rest) > 0 {
+		t := rest[0]
+		kw := t.Upper()
+		if kw == "ANALYZE" || kw == "ANALYSE" {
+			analyze = true
+		}
+		if t.Kind == Punct && t.Text == "(" {
+			// EXPLAIN (ANALYZE, BUFFERS) ...
+			for len(rest) > 0 && !(rest[0].Kind == Punct && rest[0].Text == ")") {
+				if u := rest[0].Upper(); u == "ANALYZE" || u == "ANALYSE" {
+					analyze = true
+				}
+				rest = rest[1:]
+			}
+		}
+		if readKeywords[kw] || writeKeywords[kw] || kw == "WITH" {
+			break
+		}
+		if len(rest) > 0 {
+			rest = rest[1:]
+		}
+	}
+	if analyze {
+		if inner := classifyTokens(rest); inner.Kind != KindRead && inner.Kind != KindUnknown {
+			st.Kind = inner.Kind
+		}
+	}
+	return st
+}
+
+func nextWord(tokens []Token) string {
+	for _, t := range tokens {
+		if t.Kind == Space || t.Kind == Comment {
+			continue
+		}
+		return t.Upper()
+	}
+	return ""
+}
+
+func hasTopLevel(tokens []Token, word string) bool {
+	depth := 0
+	for _, t := range tokens {
+		if t.Kind == Punct {
+			switch t.Text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+			continue
+		}
+		if depth == 0 && t.Upper() == word {
+			return true
+		}
+	}
+	return false
+}
+
+// pragmaName возвращает имя PRAGMA без схемы: PRAGMA main.table_info(t) -> TABLE_INFO
+func pragmaName(tokens []Token) string {
+	name := ""
+	for _, t := range tokens[1:] {
+		if t.Kind == Punct && t.Text == "(" {
+			break
+		}
+		if t.Kind == Word {
+			name = t.Upper()
+		}
+	}
+	return name
+}
+
+func hasPunct(tokens []Token, p string) bool {
+	for _, t := range tokens {
+		if t.Kind == Punct && t.Text == p {
+			return true
+		}
+	}
+	return false
+}
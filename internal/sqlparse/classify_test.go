@@ -0,0 +1,53 @@
+package sqlparse
+
+import (
+	"smartTables/internal/dialect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		src  string
+		d    dialect.Dialect
+		kind Kind
+		rows bool
+	}{
+		{"select * from t where note = 'UPDATE'", dialect.Postgres, KindRead, true},
+		{"select * from t where note = 'insert into x'", dialect.MySQL, KindRead, true},
+		{"SELECT 1 -- DELETE FROM t", dialect.Postgres, KindRead, true},
+		{"/* UPDATE t */ SELECT 1", dialect.Postgres, KindRead, true},
+		{"select $$DELETE FROM t$$", dialect.Postgres, KindRead, true},
+		{`select "update" from t`, dialect.Postgres, KindRead, true},
+		{"select `delete` from t", dialect.MySQL, KindRead, true},
+		{"insert into t values (1)", dialect.Postgres, KindWrite, false},
+		{"Update t set a = 1", dialect.MySQL, KindWrite, false},
+		{"delete from t returning id", dialect.Postgres, KindWrite, true},
+		{"merge into t using s on t.id = s.id when matched then delete", dialect.Postgres, KindWrite, false},
+		{"select * into t2 from t", dialect.Postgres, KindWrite, false},
+		{"(select 1) union (select 2)", dialect.Postgres, KindRead, true},
+		{"with x as (select 1) select * from x", dialect.Postgres, KindRead, true},
+		{"WITH gone AS (DELETE FROM t RETURNING *) SELECT count(*) FROM gone", dialect.Postgres, KindWrite, true},
+		{"with x as (select 1) delete from t where id in (select * from x)", dialect.Postgres, KindWrite, false},
+		{"explain select 1", dialect.Postgres, KindRead, true},
+		{"explain analyze delete from t", dialect.Postgres, KindWrite, true},
+		{"create table t (id int)", dialect.Postgres, KindDDL, false},
+		{"drop table t", dialect.SQLite, KindDDL, false},
+		{"begin", dialect.Postgres, KindTransaction, false},
+		{"set transaction read only", dialect.Postgres, KindTransaction, false},
+		{"set search_path to x", dialect.Postgres, KindOther, false},
+		{"pragma table_info(t)", dialect.SQLite, KindRead, true},
+		{"pragma user_version = 5", dialect.SQLite, KindOther, false},
+		{"pragma user_version(5)", dialect.SQLite, KindOther, false},
+		{"PRAGMA query_only(0)", dialect.SQLite, KindOther, false},
+		{"pragma main.table_info(t)", dialect.SQLite, KindRead, true},
+		{"pragma user_version", dialect.SQLite, KindRead, true},
+		{";; select 1", dialect.Postgres, KindRead, true},
+		{"-- nothing", dialect.Postgres, KindUnknown, false},
+	}
+	for _, tt := range tests {
+		st := Classify(tt.src, tt.d)
+		if st.Kind != tt.kind || st.ReturnsRows != tt.rows {
+			t.Errorf("Classify(%q): got %v/%v, want %v/%v", tt.src, st.Kind, st.ReturnsRows, tt.kind, tt.rows)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package sqlparse
+
+import (
+	"smartTables/internal/dialect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	Space TokenKind = iota
+	Comment
+	Word
+	QuotedIdent
+	String
+	Number
+	Punct
+)
+
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  int
+}
+
+// Upper возвращает слово в верхнем регистре, для остальных токенов - пустую строку
+func (t Token) Upper() string {
+	if t.Kind != Word {
+		return ""
+	}
+	return strings.ToUpper(t.Text)
+}
+
+// Tokenize разбивает текст на токены, не заглядывая внутрь строк, комментариев и $$-блоков.
+// Пустой диалект разбирается по правилам Postgres.
+func Tokenize(src string, d dialect.Dialect) []Token {
+	l := lexer{src: src, mysql: d == dialect.MySQL, dollar: d == dialect.Postgres || d == ""}
+	var tokens []Token
+	for l.pos < len(src) {
+		start := l.pos
+		kind := l.next()
+		tokens = append(tokens, Token{Kind: kind, Text: src[start:l.pos], Pos: start})
+	}
+	return tokens
+}
+
+type lexer struct {
+	src string
+	pos int
+	// mysql: комментарии через #, строки в двойных кавычках и экранирование обратным слэшем
+	mysql bool
+	// dollar: строки вида $tag$...$tag$
+	dollar bool
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *lexer) next() TokenKind {
+	c := l.src[l.pos]
+	switch {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+		for l.pos < len(l.src) && strings.IndexByte(" \t\n\r\f", l.src[l.pos]) >= 0 {
+			l.pos++
+		}
+		return Space
+	case c == '-' && l.peek(1) == '-':
+		l.lineComment()
+		return Comment
+	case c == '#' && l.mysql:
+		l.lineComment()
+		return Comment
+	case c == '/' && l.peek(1) == '*':
+		l.blockComment()
+		return Comment
+	case c == '\'':
+		l.quoted('\'', l.mysql)
+		return String
+	case c == '"' && l.mysql:
+		l.quoted('"', true)
+		return String
+	case (c == 'E' || c == 'e') && l.peek(1) == '\'':
+		l.pos++
+		l.quoted('\'', true)
+		return String
+	case c == '"':
+		l.quoted('"', false)
+		return QuotedIdent
+	case c == '`':
+		l.quoted('`', false)
+		return QuotedIdent
+	case c == '$':
+		if l.dollar && l.dollarQuoted() {
+			return String
+		}
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return Punct
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.number()
+		return Number
+	case isIdentStart(l.src[l.pos:]):
+		l.word()
+		return Word
+	}
+	_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	l.pos += size
+	return Punct
+}
+
+func (l *lexer) lineComment() {
+	end := strings.IndexByte(l.src[l.pos:], '\n')
+	if end < 0 {
+		l.pos = len(l.src)
+		return
+	}
+	l.pos += end
+}
+
+// blockComment учитывает вложенные комментарии, как в Postgres
+func (l *lexer) blockComment() {
+	depth := 0
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '/' && l.peek(1) == '*':
+			depth++
+			l.pos += 2
+		case l.src[l.pos] == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return
+			}
+		default:
+			l.pos++
+		}
+	}
+}
+
+// quoted читает литерал до закрывающей кавычки, удвоенная кавычка считается экранированной
+func (l *lexer) quoted(quote byte, backslash bool) {
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case backslash && c == '\\':
+			l.pos += 2
+		case c == quote && l.peek(1) == quote:
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			return
+		default:
+			l.pos++
+		}
+	}
+	if l.pos > len(l.src) {
+		l.pos = len(l.src)
+	}
+}
+
+// dollarQuoted читает $tag$...$tag$, возвращает false, если это не начало такого блока
+func (l *lexer) dollarQuoted() bool {
+	end := l.pos + 1
+	for end < len(l.src) && l.src[end] != '$' {
+		c := l.src[end]
+		if !(c == '_' || isLetter(c) || (end > l.pos+1 && isDigit(c))) {
+			return false
+		}
+		end++
+	}
+	if end >= len(l.src) {
+		return false
+	}
+	tag := l.src[l.pos : end+1]
+	closing := strings.Index(l.src[end+1:], tag)
+	if closing < 0 {
+		l.pos = len(l.src)
+		return true
+	}
+	l.pos = end + 1 + closing + len(tag)
+	return true
+}
+
+func (l *lexer) number() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isDigit(c) || c == '.' || c == '_' {
+			l.pos++
+			continue
+		}
+		if (c == 'e' || c == 'E') && (isDigit(l.peek(1)) || ((l.peek(1) == '+' || l.peek(1) == '-') && isDigit(l.peek(2)))) {
+			l.pos += 2
+			continue
+		}
+		return
+	}
+}
+
+func (l *lexer) word() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+func isIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
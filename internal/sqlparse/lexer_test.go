@@ -0,0 +1,42 @@
+package sqlparse
+
+import (
+	"smartTables/internal/dialect"
+	"testing"
+)
+
+func TestTokenizeLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    dialect.Dialect
+		want []Token
+	}{
+		{"doubled quote", `'it''s'`, dialect.Postgres, []Token{{String, `'it''s'`, 0}}},
+		{"escape string", `E'a\'b' x`, dialect.Postgres, []Token{{String, `E'a\'b'`, 0}, {Space, " ", 7}, {Word, "x", 8}}},
+		{"dollar quoted", `$$ ; 'x $$`, dialect.Postgres, []Token{{String, `$$ ; 'x $$`, 0}}},
+		{"tagged dollar quoted", `$fn$ $$ ; $fn$`, dialect.Postgres, []Token{{String, `$fn$ $$ ; $fn$`, 0}}},
+		{"positional param", `$1`, dialect.Postgres, []Token{{Punct, "$1", 0}}},
+		{"nested comment", `/* a /* b */ ; */x`, dialect.Postgres, []Token{{Comment, `/* a /* b */ ; */`, 0}, {Word, "x", 17}}},
+		{"line comment", "-- ;\nx", dialect.Postgres, []Token{{Comment, "-- ;", 0}, {Space, "\n", 4}, {Word, "x", 5}}},
+		{"mysql hash comment", "# ;\nx", dialect.MySQL, []Token{{Comment, "# ;", 0}, {Space, "\n", 3}, {Word, "x", 4}}},
+		{"mysql double quoted string", `"a;b"`, dialect.MySQL, []Token{{String, `"a;b"`, 0}}},
+		{"postgres double quoted ident", `"a;b"`, dialect.Postgres, []Token{{QuotedIdent, `"a;b"`, 0}}},
+		{"backtick ident", "`a;b`", dialect.MySQL, []Token{{QuotedIdent, "`a;b`", 0}}},
+		{"mysql backslash escape", `'a\';'`, dialect.MySQL, []Token{{String, `'a\';'`, 0}}},
+		{"number with exponent", `1.5e-3`, dialect.Postgres, []Token{{Number, "1.5e-3", 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Tokenize(tt.src, tt.d)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Tokenize(%q): got %+v, want %+v", tt.src, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Tokenize(%q)[%d]: got %+v, want %+v", tt.src, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package sqlparse
+
+import (
+	"smartTables/internal/dialect"
+	"strings"
+)
+
+// Param - именованный параметр запроса: :name или {{name}}.
+// Во втором виде можно указать тип: {{name:integer}}.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+type paramRef struct {
+	Param
+	start, end int
+}
+
+// findParams ищет параметры вне строк и комментариев. Приведение типа Postgres (::type),
+// присваивание MySQL (:=) и срезы массивов arr[1:n] параметрами не считаются.
+func findParams(src string, d dialect.Dialect) []paramRef {
+	tokens := Tokenize(src, d)
+	var res []paramRef
+	brackets := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.Kind != Punct {
+			continue
+		}
+		switch t.Text {
+		case "[":
+			brackets++
+		case "]":
+			if brackets > 0 {
+				brackets--
+			}
+		case ":":
+			if brackets > 0 || (i > 0 && tokens[i-1].Text == ":") {
+				continue
+			}
+			if i+1 < len(tokens) && tokens[i+1].Kind == Word {
+				res = append(res, paramRef{Param: Param{Name: tokens[i+1].Text}, start: t.Pos, end: t.Pos + 1 + len(tokens[i+1].Text)})
+				i++
+			}
+		case "{":
+			if ref, next, ok := braceParam(tokens, i); ok {
+				res = append(res, ref)
+				i = next
+			}
+		}
+	}
+	return res
+}
+
+// braceParam разбирает {{ name }} или {{ name:type }}, начиная с токена i
+func braceParam(tokens []Token, i int) (paramRef, int, bool) {
+	j := i + 1
+	skipSpace := func() {
+		for j < len(tokens) && tokens[j].Kind == Space {
+			j++
+		}
+	}
+	expect := func(text string) bool {
+		if j < len(tokens) && tokens[j].Kind == Punct && tokens[j].Text == text {
+			j++
+			return true
+		}
+		return false
+	}
+	word := func() (string, bool) {
+		skipSpace()
+		if j < len(tokens) && tokens[j].Kind == Word {
+			j++
+			return tokens[j-1].Text, true
+		}
+		return "", false
+	}
+
+	var ref paramRef
+	var ok bool
+	if !expect("{") {
+		return ref, 0, false
+	}
+	if ref.Name, ok = word(); !ok {
+		return ref, 0, false
+	}
+	skipSpace()
+	if expect(":") {
+		if ref.Type, ok = word(); !ok {
+			return ref, 0, false
+		}
+	}
+	skipSpace()
+	if !expect("}") || !expect("}") {
+		return ref, 0, false
+	}
+	ref.start = tokens[i].Pos
+	ref.end = tokens[j-1].Pos + 1
+	return ref, j - 1, true
+}
+
+// Params возвращает параметры запроса в порядке первого появления
+func Params(src string, d dialect.Dialect) []Param {
+	var res []Param
+	seen := make(map[string]int)
+	for _, ref := range findParams(src, d) {
+		if i, ok := seen[ref.Name]; ok {
+			if res[i].Type == "" {
+				res[i].Type = ref.Type
+			}
+			continue
+		}
+		seen[ref.Name] = len(res)
+		res = append(res, ref.Param)
+	}
+	return res
+}
+
+// Bind заменяет параметры плейсхолдерами драйвера и возвращает имя параметра для каждого аргумента.
+// В Postgres повторный параметр ссылается на тот же $n, и если для него задан тип в casts,
+// плейсхолдер оборачивается в CAST; в остальных базах каждое вхождение - отдельный ?.
+func Bind(src string, d dialect.Dialect, casts map[string]string) (string, []string) {
+	var (
+		sb    strings.Builder
+		names []string
+		last  int
+	)
+	index := make(map[string]int)
+	for _, ref := range findParams(src, d) {
+		sb.WriteString(src[last:ref.start])
+		last = ref.end
+		if d != dialect.Postgres {
+			names = append(names, ref.Name)
+			sb.WriteString(d.Placeholder(len(names)))
+			continue
+		}
+		n, ok := index[ref.Name]
+		if !ok {
+			names = append(names, ref.Name)
+			n = len(names)
+			index[ref.Name] = n
+		}
+		if t := casts[ref.Name]; t != "" {
+			sb.WriteString("CAST(" + d.Placeholder(n) + " AS " + t + ")")
+		} else {
+			sb.WriteString(d.Placeholder(n))
+		}
+	}
+	sb.WriteString(src[last:])
+	return sb.String(), names
+}
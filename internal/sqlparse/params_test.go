@@ -0,0 +1,48 @@
+package sqlparse
+
+import (
+	"reflect"
+	"smartTables/internal/dialect"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	tests := []struct {
+		src  string
+		d    dialect.Dialect
+		want []Param
+	}{
+		{"select * from t where id = :id and name = :name", dialect.Postgres, []Param{{Name: "id"}, {Name: "name"}}},
+		{"select :id::int, :id", dialect.Postgres, []Param{{Name: "id"}}},
+		{"select a::text from t", dialect.Postgres, nil},
+		{"select arr[1:n], arr[:n] from t where id = :id", dialect.Postgres, []Param{{Name: "id"}}},
+		{"set @x := 1", dialect.MySQL, nil},
+		{"select ':id' -- :other\n", dialect.Postgres, nil},
+		{"select {{ limit : integer }}, {{limit}}, {{name}}", dialect.Postgres, []Param{{Name: "limit", Type: "integer"}, {Name: "name"}}},
+	}
+	for _, tt := range tests {
+		if got := Params(tt.src, tt.d); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Params(%q): got %+v, want %+v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		src   string
+		d     dialect.Dialect
+		casts map[string]string
+		query string
+		names []string
+	}{
+		{"select :a, :b, :a", dialect.Postgres, nil, "select $1, $2, $1", []string{"a", "b"}},
+		{"select :a, :b, :a", dialect.MySQL, nil, "select ?, ?, ?", []string{"a", "b", "a"}},
+		{"select {{n:integer}}::text, arr[1:n]", dialect.Postgres, map[string]string{"n": "integer"}, "select CAST($1 AS integer)::text, arr[1:n]", []string{"n"}},
+	}
+	for _, tt := range tests {
+		query, names := Bind(tt.src, tt.d, tt.casts)
+		if query != tt.query || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("Bind(%q): got %q %v, want %q %v", tt.src, query, names, tt.query, tt.names)
+		}
+	}
+}
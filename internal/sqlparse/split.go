@@ -0,0 +1,134 @@
+package sqlparse
+
+import (
+	"smartTables/internal/dialect"
+	"strings"
+)
+
+// Split делит скрипт на отдельные операторы.
+// Учитывает строки, комментарии, $$-блоки Postgres, команду DELIMITER клиента mysql
+// и тела CREATE TRIGGER/FUNCTION/PROCEDURE с BEGIN ... END.
+func Split(src string, d dialect.Dialect) []string {
+	tokens := Tokenize(src, d)
+	var (
+		res       []string
+		start     int
+		delimiter = ";"
+		block     int
+		head      []string
+	)
+
+	flush := func(end int) {
+		if stmt := strings.TrimSpace(src[start:end]); stmt != "" && !onlyComments(stmt, d) {
+			res = append(res, stmt)
+		}
+		head = head[:0]
+		block = 0
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.Kind {
+		case Space, Comment:
+			continue
+		case Word:
+			kw := t.Upper()
+			if d == dialect.MySQL && kw == "DELIMITER" && len(head) == 0 {
+				// DELIMITER $$ - директива клиента, сам оператор не отправляется
+				lineEnd := strings.IndexByte(src[t.Pos:], '\n')
+				if lineEnd < 0 {
+					lineEnd = len(src) - t.Pos
+				}
+				if fields := strings.Fields(src[t.Pos : t.Pos+lineEnd]); len(fields) > 1 {
+					delimiter = fields[1]
+				}
+				start = t.Pos + lineEnd
+				for i+1 < len(tokens) && tokens[i+1].Pos < start {
+					i++
+				}
+				continue
+			}
+			if at := delimiterIn(src, t, delimiter); at > 0 {
+				// в MySQL '$' допустим в имени, поэтому END$$ читается одним словом
+				flush(t.Pos + at)
+				start = t.Pos + at + len(delimiter)
+				for i+1 < len(tokens) && tokens[i+1].Pos < start {
+					i++
+				}
+				continue
+			}
+			if len(head) < headSize {
+				head = append(head, kw)
+			}
+			// со своим разделителем клиент не разбирает тела, ';' внутри них и так не разделяет операторы
+			if delimiter == ";" && isRoutine(head) {
+				switch kw {
+				case "BEGIN", "CASE":
+					block++
+				case "END":
+					// END IF / END LOOP закрывают конструкции, которые мы не считали
+					if next := nextWord(tokens[i+1:]); block > 0 && next != "IF" && next != "LOOP" && next != "WHILE" && next != "REPEAT" {
+						block--
+					}
+				}
+			}
+		case Punct:
+			if block == 0 && strings.HasPrefix(src[t.Pos:], delimiter) {
+				flush(t.Pos)
+				start = t.Pos + len(delimiter)
+				for i+1 < len(tokens) && tokens[i+1].Pos < start {
+					i++
+				}
+				continue
+			}
+			if len(head) < headSize {
+				head = append(head, t.Text)
+			}
+		default:
+			if len(head) < headSize {
+				head = append(head, t.Text)
+			}
+		}
+	}
+	flush(len(src))
+	return res
+}
+
+// delimiterIn возвращает смещение разделителя внутри слова или 0, если его там нет
+func delimiterIn(src string, t Token, delimiter string) int {
+	if delimiter == ";" {
+		return 0
+	}
+	for at := 1; at < len(t.Text); at++ {
+		if strings.HasPrefix(src[t.Pos+at:], delimiter) {
+			return at
+		}
+	}
+	return 0
+}
+
+// headSize - сколько первых токенов оператора нужно, чтобы узнать CREATE ... TRIGGER
+const headSize = 8
+
+// isRoutine проверяет, что оператор создает триггер или процедуру, тело которых может содержать ';'
+func isRoutine(head []string) bool {
+	if len(head) < 2 || head[0] != "CREATE" {
+		return false
+	}
+	for _, w := range head[1:] {
+		switch w {
+		case "TRIGGER", "FUNCTION", "PROCEDURE", "EVENT":
+			return true
+		}
+	}
+	return false
+}
+
+func onlyComments(stmt string, d dialect.Dialect) bool {
+	for _, t := range Tokenize(stmt, d) {
+		if t.Kind != Space && t.Kind != Comment {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package sqlparse
+
+import (
+	"reflect"
+	"smartTables/internal/dialect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    dialect.Dialect
+		want []string
+	}{
+		{"simple", "select 1; select 2;", dialect.Postgres, []string{"select 1", "select 2"}},
+		{"semicolon in string", "select ';'; select 2", dialect.Postgres, []string{"select ';'", "select 2"}},
+		{"semicolon in comment", "select 1 -- ;\n; /* ; */", dialect.Postgres, []string{"select 1 -- ;"}},
+		{
+			"dollar quoted body",
+			"create function f() returns int as $$ begin return 1; end; $$ language plpgsql; select f()",
+			dialect.Postgres,
+			[]string{"create function f() returns int as $$ begin return 1; end; $$ language plpgsql", "select f()"},
+		},
+		{
+			"sqlite trigger body",
+			"create trigger tr after insert on t begin update t set a = 1; delete from u; end; select 1",
+			dialect.SQLite,
+			[]string{"create trigger tr after insert on t begin update t set a = 1; delete from u; end", "select 1"},
+		},
+		{
+			"mysql procedure with if",
+			"create procedure p() begin if 1 then select 1; end if; select 2; end; call p()",
+			dialect.MySQL,
+			[]string{"create procedure p() begin if 1 then select 1; end if; select 2; end", "call p()"},
+		},
+		{
+			"mysql delimiter",
+			"DELIMITER //\ncreate procedure p() begin select 1; end //\nDELIMITER ;\ncall p();",
+			dialect.MySQL,
+			[]string{"create procedure p() begin select 1; end", "call p()"},
+		},
+		{
+			"mysql delimiter after end",
+			"DELIMITER $$\ncreate procedure p() begin if 1 then select 1; end if; end$$\nDELIMITER ;\ncall p();",
+			dialect.MySQL,
+			[]string{"create procedure p() begin if 1 then select 1; end if; end", "call p()"},
+		},
+		{"mysql backtick", "select `a;b` from t; select 2", dialect.MySQL, []string{"select `a;b` from t", "select 2"}},
+		{"empty", " ; -- x\n", dialect.Postgres, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.src, tt.d); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Split(%q): got %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strings"
+)
+
+func (s *Storage) GetUserRole(ctx context.Context, user string) (string, error) {
+	var role string
+	err := s.conn.QueryRowContext(ctx, `SELECT role FROM users WHERE login = $1`, user).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: user %s", constants.ErrNotFound, user)
+		}
+		return "", fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return role, nil
+}
+
+func (s *Storage) ListUsers(ctx context.Context) ([]shema.User, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT login, role FROM users ORDER BY login`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.User, 0)
+	for rows.Next() {
+		var u shema.User
+		if err := rows.Scan(&u.Login, &u.Role); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, u)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *Storage) SetUserRole(ctx context.Context, user, role string) error {
+	res, err := s.conn.ExecContext(ctx, `UPDATE users SET role = $1 WHERE login = $2`, role, user)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: user %s", constants.ErrNotFound, user)
+	}
+	return nil
+}
+
+// ListConnectionAccess возвращает все сохраненные подключения с пользователями, которым они выданы
+func (s *Storage) ListConnectionAccess(ctx context.Context) ([]shema.ConnectionAccess, error) {
+	query := `SELECT c.id, COALESCE(c.login, ''), c.typeDB, c.dbName, c.readOnly, COALESCE(t.name, ''),
+			ARRAY(SELECT g.login FROM connection_grants g WHERE g.connection_id = c.id ORDER BY g.login)
+		FROM connections c
+		LEFT JOIN teams t ON t.id = c.team_id
+		ORDER BY c.login, c.dbName, c.id`
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.ConnectionAccess, 0)
+	for rows.Next() {
+		var c shema.ConnectionAccess
+		if err := rows.Scan(&c.ID, &c.Owner, &c.TypeDB, &c.DBName, &c.ReadOnly, &c.Team, pq.Array(&c.Grants)); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, c)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *Storage) GrantConnection(ctx context.Context, g shema.ConnectionGrant) error {
+	_, err := s.conn.ExecContext(ctx, `
+		INSERT INTO connection_grants (connection_id, login) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, g.ConnectionID, g.Login)
+	if err != nil {
+		if strings.Contains(err.Error(), "foreign key constraint") {
+			return fmt.Errorf("%w: no such connection or user", constants.ErrNotFound)
+		}
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+func (s *Storage) RevokeConnection(ctx context.Context, g shema.ConnectionGrant) error {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM connection_grants WHERE connection_id = $1 AND login = $2`,
+		g.ConnectionID, g.Login)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: no such grant", constants.ErrNotFound)
+	}
+	return nil
+}
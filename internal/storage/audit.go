@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"smartTables/internal/shema"
+)
+
+// SaveAuditEvent добавляет событие в журнал. Таблица audit_log только дополняется: UPDATE и DELETE запрещены триггером.
+func (s *Storage) SaveAuditEvent(ctx context.Context, e shema.AuditEvent) error {
+	sqlStatement := `
+		INSERT INTO audit_log (time, actor, action, success, connectionId, typeDB, dbName, queryHash, clientIP, detail)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	connectionID := sql.NullInt64{Int64: e.ConnectionID, Valid: e.ConnectionID != 0}
+	_, err := s.conn.ExecContext(ctx, sqlStatement, e.Time, e.Actor, e.Action, e.Success, connectionID, e.TypeDB,
+		e.DBName, e.QueryHash, e.ClientIP, e.Detail)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+// GetAuditEvents возвращает страницу журнала, новые события первыми; читается на одно событие больше limit
+func (s *Storage) GetAuditEvents(ctx context.Context, filter shema.AuditFilter) ([]shema.AuditEvent, error) {
+	sqlStatement := `
+		SELECT id, time, actor, action, success, connectionId, typeDB, dbName, queryHash, clientIP, detail
+		FROM audit_log
+		WHERE ($1 = '' OR actor = $1)
+		  AND ($2 = '' OR action = $2)
+		  AND ($3 = '' OR dbName = $3)
+		  AND ($4 = '' OR queryHash = $4)
+		  AND ($5 = '' OR time >= NULLIF($5, '')::date)
+		  AND ($6 = '' OR time < NULLIF($6, '')::date + 1)
+		ORDER BY time DESC, id DESC
+		LIMIT $7 OFFSET $8`
+	rows, err := s.conn.QueryContext(ctx, sqlStatement, filter.Actor, filter.Action, filter.DBName, filter.QueryHash,
+		filter.From, filter.To, filter.Limit+1, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	events := make([]shema.AuditEvent, 0)
+	for rows.Next() {
+		var e shema.AuditEvent
+		var connectionID sql.NullInt64
+		err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Action, &e.Success, &connectionID, &e.TypeDB, &e.DBName,
+			&e.QueryHash, &e.ClientIP, &e.Detail)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		e.ConnectionID = connectionID.Int64
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strings"
+)
+
+const savedQueryColumns = `q.id, q.login, q.name, q.description, q.query, q.typeDB, q.tags, q.params, q.created, q.updated,
+	ARRAY(SELECT s.login FROM saved_query_shares s WHERE s.query_id = q.id ORDER BY s.login)`
+
+// запрос виден владельцу и тем, с кем им поделились
+const savedQueryVisible = `(q.login = $1 OR EXISTS (SELECT 1 FROM saved_query_shares s WHERE s.query_id = q.id AND s.login = $1))`
+
+func scanSavedQuery(row interface{ Scan(...interface{}) error }) (shema.SavedQuery, error) {
+	var q shema.SavedQuery
+	var params []byte
+	err := row.Scan(&q.ID, &q.Owner, &q.Name, &q.Description, &q.Query, &q.TypeDB, pq.Array(&q.Tags), &params,
+		&q.Created, &q.Updated, pq.Array(&q.SharedWith))
+	if err != nil {
+		return q, err
+	}
+	if err := json.Unmarshal(params, &q.Params); err != nil {
+		return q, fmt.Errorf("bad params of saved query %d: %v", q.ID, err)
+	}
+	return q, nil
+}
+
+func (s *Storage) ListSavedQueries(ctx context.Context, user string, filter shema.SavedQueryFilter) ([]shema.SavedQuery, error) {
+	query := `SELECT ` + savedQueryColumns + `
+		FROM saved_queries q
+		WHERE ` + savedQueryVisible + `
+		  AND ($2 = '' OR q.name ILIKE '%' || $2 || '%' OR q.description ILIKE '%' || $2 || '%' OR q.query ILIKE '%' || $2 || '%')
+		  AND ($3 = '' OR $3 = ANY(q.tags))
+		ORDER BY q.name, q.id`
+
+	rows, err := s.conn.QueryContext(ctx, query, user, escapeLike(filter.Search), filter.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.SavedQuery, 0)
+	for rows.Next() {
+		q, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, q)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *Storage) GetSavedQuery(ctx context.Context, user string, id int64) (shema.SavedQuery, error) {
+	query := `SELECT ` + savedQueryColumns + ` FROM saved_queries q WHERE ` + savedQueryVisible + ` AND q.id = $2`
+
+	q, err := scanSavedQuery(s.conn.QueryRowContext(ctx, query, user, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return q, fmt.Errorf("%w: saved query %d", constants.ErrNotFound, id)
+		}
+		return q, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return q, nil
+}
+
+func (s *Storage) CreateSavedQuery(ctx context.Context, q shema.SavedQuery) (int64, error) {
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction. %v", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO saved_queries (login, name, description, query, typeDB, tags, params)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		q.Owner, q.Name, q.Description, q.Query, q.TypeDB, pq.Array(q.Tags), params).Scan(&id)
+	if err != nil {
+		return 0, savedQueryErr(err)
+	}
+	if err := saveShares(ctx, tx, id, q.SharedWith); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("unable to commit. %v", err)
+	}
+	return id, nil
+}
+
+// UpdateSavedQuery меняет запрос владельца q.Owner; чужой запрос считается не найденным
+func (s *Storage) UpdateSavedQuery(ctx context.Context, q shema.SavedQuery) error {
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return err
+	}
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction. %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE saved_queries
+		SET name = $3, description = $4, query = $5, typeDB = $6, tags = $7, params = $8, updated = NOW()
+		WHERE id = $1 AND login = $2`,
+		q.ID, q.Owner, q.Name, q.Description, q.Query, q.TypeDB, pq.Array(q.Tags), params)
+	if err != nil {
+		return savedQueryErr(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: saved query %d", constants.ErrNotFound, q.ID)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM saved_query_shares WHERE query_id = $1`, q.ID); err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if err := saveShares(ctx, tx, q.ID, q.SharedWith); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit. %v", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteSavedQuery(ctx context.Context, user string, id int64) error {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM saved_queries WHERE id = $1 AND login = $2`, id, user)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: saved query %d", constants.ErrNotFound, id)
+	}
+	return nil
+}
+
+func saveShares(ctx context.Context, tx *sql.Tx, id int64, users []string) error {
+	if len(users) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO saved_query_shares (query_id, login)
+		SELECT $1, u.login FROM users u WHERE u.login = ANY($2)`, id, pq.Array(users))
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+func savedQueryErr(err error) error {
+	if strings.Contains(err.Error(), "unique constraint") {
+		return fmt.Errorf("%w: saved query with this name already exists", constants.ErrAlreadyExists)
+	}
+	return fmt.Errorf("unable to execute the query. %v", err)
+}
+
+// escapeLike экранирует % и _ для поиска подстроки через LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+)
+
+// LoadSession возвращает неистекшую сессию
+func (s *Storage) LoadSession(ctx context.Context, id string) (shema.Session, error) {
+	query := `SELECT id, COALESCE(login, ''), data, clientIP, userAgent, created, updated, expires
+			  FROM sessions
+			  WHERE id = $1 AND expires > NOW()`
+
+	var e shema.Session
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(&e.ID, &e.Login, &e.Data, &e.ClientIP, &e.UserAgent,
+		&e.Created, &e.Updated, &e.Expires)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return e, constants.ErrNotFound
+		}
+		return e, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return e, nil
+}
+
+// SaveSession создает или обновляет сессию и заодно удаляет истекшие: отдельной задачи для этого нет
+func (s *Storage) SaveSession(ctx context.Context, e shema.Session) error {
+	query := `WITH expired AS (DELETE FROM sessions WHERE expires <= NOW() AND id <> $1)
+			  INSERT INTO sessions (id, login, data, clientIP, userAgent, expires)
+			  VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
+			  ON CONFLICT (id) DO UPDATE
+			  SET login = EXCLUDED.login, data = EXCLUDED.data, clientIP = EXCLUDED.clientIP,
+			      userAgent = EXCLUDED.userAgent, updated = NOW(), expires = EXCLUDED.expires`
+
+	_, err := s.conn.ExecContext(ctx, query, e.ID, e.Login, e.Data, e.ClientIP, e.UserAgent, e.Expires)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+// DeleteSession удаляет сессию; login, если не пустой, должен совпадать с владельцем сессии
+func (s *Storage) DeleteSession(ctx context.Context, login, id string) error {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND ($2 = '' OR login = $2)`, id, login)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: session", constants.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteUserSessions удаляет все сессии пользователя и возвращает их число
+func (s *Storage) DeleteUserSessions(ctx context.Context, login string) (int64, error) {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM sessions WHERE login = $1`, login)
+	if err != nil {
+		return 0, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *Storage) ListSessions(ctx context.Context, login string) ([]shema.Session, error) {
+	query := `SELECT id, login, clientIP, userAgent, created, updated, expires
+			  FROM sessions
+			  WHERE login = $1 AND expires > NOW()
+			  ORDER BY updated DESC`
+
+	rows, err := s.conn.QueryContext(ctx, query, login)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.Session, 0)
+	for rows.Next() {
+		var e shema.Session
+		if err := rows.Scan(&e.ID, &e.Login, &e.ClientIP, &e.UserAgent, &e.Created, &e.Updated, &e.Expires); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
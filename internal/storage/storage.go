@@ -10,8 +10,9 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	"smartTables/config"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
 	"strings"
-	"time"
 )
 
 type Storage struct {
@@ -80,22 +81,35 @@ func (s *Storage) Login(ctx context.Context, user string) ([]byte, error) {
 	return dbPassword, nil
 }
 
-func (s *Storage) SaveConnection(ctx context.Context, user, typeDB, dbname, connectionString string) error {
-	sqlStatement := `INSERT INTO connections (login, typeDB, dbName, connectionString) VALUES ($1, $2, $3, $4)`
-	_, err := s.conn.ExecContext(ctx, sqlStatement, user, typeDB, dbname, connectionString)
+// SaveConnection сохраняет подключение; teamID 0 - личное подключение user
+func (s *Storage) SaveConnection(ctx context.Context, user, typeDB, dbname, connectionString string, readOnly bool, teamID int64) (int64, error) {
+	sqlStatement := `INSERT INTO connections (login, typeDB, dbName, connectionString, readOnly, team_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	var id int64
+	team := sql.NullInt64{Int64: teamID, Valid: teamID != 0}
+	err := s.conn.QueryRowContext(ctx, sqlStatement, user, typeDB, dbname, connectionString, readOnly, team).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("unable to execute the query. %v", err)
+		return 0, fmt.Errorf("unable to execute the query. %v", err)
 	}
-	return nil
+	return id, nil
 }
 
-func (s *Storage) GetLastDB(ctx context.Context, user string) (map[string]string, error) {
-	result := make(map[string]string)
-
-	query := `SELECT c.connectionString, c.dbName
+func (s *Storage) GetLastDB(ctx context.Context, user string) ([]shema.SavedConnection, error) {
+	// свои подключения, с которыми работали за последние 30 дней, подключения команд пользователя
+	// и выданные ему администратором
+	query := `SELECT * FROM (
+				  SELECT DISTINCT ON (c.dbName) c.id, c.typeDB, c.dbName, c.connectionString, c.readOnly, FALSE, 0, ''
+				  FROM connections c
+				  JOIN history h ON c.dbName = h.dbName AND c.login = h.login
+				  WHERE c.login = $1 AND c.team_id IS NULL AND h.time > NOW() - INTERVAL '30 days'
+				  ORDER BY c.dbName, c.id DESC
+			  ) own
+			  UNION
+			  SELECT c.id, c.typeDB, c.dbName, c.connectionString, c.readOnly, TRUE, COALESCE(t.id, 0), COALESCE(t.name, '')
 			  FROM connections c
-			  JOIN history h ON c.dbName = h.dbName AND c.login = h.login
-			  WHERE c.login = $1 AND h.time > NOW() - INTERVAL '30 days'`
+			  LEFT JOIN teams t ON t.id = c.team_id
+			  WHERE EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = c.team_id AND m.login = $1)
+			     OR (c.login IS DISTINCT FROM $1
+			         AND EXISTS (SELECT 1 FROM connection_grants g WHERE g.connection_id = c.id AND g.login = $1))`
 
 	rows, err := s.conn.QueryContext(ctx, query, user)
 	if err != nil {
@@ -103,65 +117,139 @@ func (s *Storage) GetLastDB(ctx context.Context, user string) (map[string]string
 	}
 	defer rows.Close()
 
+	result := make([]shema.SavedConnection, 0)
 	for rows.Next() {
-		var connectionString, dbName string
-		if err := rows.Scan(&connectionString, &dbName); err != nil {
+		var c shema.SavedConnection
+		if err := rows.Scan(&c.ID, &c.TypeDB, &c.DBName, &c.ConnectionString, &c.ReadOnly, &c.Shared, &c.TeamID, &c.Team); err != nil {
 			return nil, fmt.Errorf("unable to scan the row. %v", err)
 		}
-		result[dbName] = connectionString
+		result = append(result, c)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
-func (s *Storage) GetTypeDB(ctx context.Context, user, dbName, connStr string) (string, error) {
-	var typeDB string
+func (s *Storage) GetSavedConnection(ctx context.Context, user string, id int64) (shema.SavedConnection, error) {
+	c := shema.SavedConnection{ID: id}
 
-	query := `SELECT typeDB FROM connections WHERE login = $1 AND dbName = $2 AND connectionString = $3`
+	// личное подключение доступно владельцу, подключение команды - ее участникам,
+	// любое - тем, кому его выдал администратор
+	query := `SELECT c.typeDB, c.dbName, c.connectionString, c.readOnly,
+				  c.team_id IS NOT NULL OR c.login IS DISTINCT FROM $1, COALESCE(t.id, 0), COALESCE(t.name, '')
+			  FROM connections c
+			  LEFT JOIN teams t ON t.id = c.team_id
+			  WHERE c.id = $2
+			    AND ((c.team_id IS NULL AND c.login = $1)
+			      OR EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = c.team_id AND m.login = $1)
+			      OR EXISTS (SELECT 1 FROM connection_grants g WHERE g.connection_id = c.id AND g.login = $1))`
+
+	err := s.conn.QueryRowContext(ctx, query, user, id).Scan(&c.TypeDB, &c.DBName, &c.ConnectionString, &c.ReadOnly,
+		&c.Shared, &c.TeamID, &c.Team)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c, constants.ErrNoConnection
+		}
+		return c, fmt.Errorf("unable to execute the query. %v", err)
+	}
 
-	err := s.conn.QueryRowContext(ctx, query, user, dbName, connStr).Scan(&typeDB)
+	return c, nil
+}
+
+// GetAllConnectionSecrets используется только для перешифрования
+func (s *Storage) GetAllConnectionSecrets(ctx context.Context) ([]shema.SavedConnection, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT id, typeDB, dbName, connectionString FROM connections ORDER BY id`)
 	if err != nil {
-		return "", fmt.Errorf("unable to execute the query. %v", err)
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	var result []shema.SavedConnection
+	for rows.Next() {
+		var c shema.SavedConnection
+		if err := rows.Scan(&c.ID, &c.TypeDB, &c.DBName, &c.ConnectionString); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, c)
 	}
 
-	return typeDB, nil
+	return result, rows.Err()
 }
 
-func (s *Storage) SaveQuery(ctx context.Context, user, typeDB, dbName, query string, time time.Time) error {
+func (s *Storage) UpdateConnectionSecret(ctx context.Context, id int64, connectionString string) error {
+	_, err := s.conn.ExecContext(ctx, `UPDATE connections SET connectionString = $1 WHERE id = $2`, connectionString, id)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+// SaveQuery добавляет запись в историю
+func (s *Storage) SaveQuery(ctx context.Context, user string, e shema.HistoryEntry) error {
 	sqlStatement := `
-		INSERT INTO history (login, typeDB, dbName, time, query)
-		VALUES ($1, $2, $3, $4, $5)`
-	_, err := s.conn.ExecContext(ctx, sqlStatement, user, typeDB, dbName, time, query)
+		INSERT INTO history (login, typeDB, dbName, time, query, status, error, durationMs, rowCount, source, clientIP)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := s.conn.ExecContext(ctx, sqlStatement, user, e.TypeDB, e.DBName, e.Time, e.Query, e.Status, e.Error,
+		e.DurationMs, e.Rows, e.Source, e.ClientIP)
 	if err != nil {
 		return fmt.Errorf("unable to execute the query. %v", err)
 	}
 	return nil
 }
 
-func (s *Storage) GetHistory(ctx context.Context, user, dbName string) ([][]string, error) {
+// GetHistory возвращает страницу истории, новые записи первыми; читается на одну запись больше limit,
+// чтобы понять, есть ли следующая страница
+func (s *Storage) GetHistory(ctx context.Context, user string, filter shema.HistoryFilter) ([]shema.HistoryEntry, error) {
 	sqlStatement := `
-		SELECT dbname, typedb, query, time
-		FROM history 
-		WHERE login = $1 AND dbName = $2`
-	rows, err := s.conn.QueryContext(ctx, sqlStatement, user, dbName)
+		SELECT id, typeDB, dbName, query, time, status, error, durationMs, rowCount, source, clientIP
+		FROM history
+		WHERE login = $1
+		  AND ($2 = '' OR query ILIKE '%' || $2 || '%')
+		  AND ($3 = '' OR time >= NULLIF($3, '')::date)
+		  AND ($4 = '' OR time < NULLIF($4, '')::date + 1)
+		  AND ($5 = '' OR typeDB = $5)
+		  AND ($6 = '' OR dbName = $6)
+		  AND ($7 = '' OR status = $7)
+		ORDER BY time DESC, id DESC
+		LIMIT $8 OFFSET $9`
+	rows, err := s.conn.QueryContext(ctx, sqlStatement, user, escapeLike(filter.Search), filter.From, filter.To,
+		filter.TypeDB, filter.DBName, filter.Status, filter.Limit+1, filter.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("unable to execute the query. %v", err)
 	}
 	defer rows.Close()
 
-	var history [][]string
+	history := make([]shema.HistoryEntry, 0)
 	for rows.Next() {
-		var dbname, typedb, query, time string
-		err = rows.Scan(&dbname, &typedb, &query, &time)
+		var e shema.HistoryEntry
+		var query sql.NullString
+		var t sql.NullTime
+		err = rows.Scan(&e.ID, &e.TypeDB, &e.DBName, &query, &t, &e.Status, &e.Error, &e.DurationMs, &e.Rows, &e.Source, &e.ClientIP)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
 		}
-		history = append(history, []string{dbname, typedb, query, time})
+		e.Query, e.Time = query.String, t.Time
+		history = append(history, e)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	return history, rows.Err()
+}
+
+// GetHistoryDatabases возвращает подключения, которые встречаются в истории пользователя
+func (s *Storage) GetHistoryDatabases(ctx context.Context, user string) ([]shema.HistoryDatabase, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT DISTINCT typeDB, dbName FROM history WHERE login = $1 ORDER BY dbName, typeDB`, user)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.HistoryDatabase, 0)
+	for rows.Next() {
+		var db shema.HistoryDatabase
+		if err := rows.Scan(&db.TypeDB, &db.DBName); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, db)
 	}
 
-	return history, nil
+	return result, rows.Err()
 }
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"smartTables/internal/constants"
+	"smartTables/internal/shema"
+	"strings"
+)
+
+const teamColumns = `t.id, t.name, ARRAY(SELECT m.login FROM team_members m WHERE m.team_id = t.id ORDER BY m.login)`
+
+func (s *Storage) listTeams(ctx context.Context, query string, args ...interface{}) ([]shema.Team, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]shema.Team, 0)
+	for rows.Next() {
+		var t shema.Team
+		if err := rows.Scan(&t.ID, &t.Name, pq.Array(&t.Members)); err != nil {
+			return nil, fmt.Errorf("unable to scan the row. %v", err)
+		}
+		result = append(result, t)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *Storage) ListTeams(ctx context.Context) ([]shema.Team, error) {
+	return s.listTeams(ctx, `SELECT `+teamColumns+` FROM teams t ORDER BY t.name`)
+}
+
+// UserTeams возвращает команды, в которых состоит пользователь
+func (s *Storage) UserTeams(ctx context.Context, user string) ([]shema.Team, error) {
+	return s.listTeams(ctx, `SELECT `+teamColumns+`
+		FROM teams t
+		WHERE EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = t.id AND m.login = $1)
+		ORDER BY t.name`, user)
+}
+
+func (s *Storage) CreateTeam(ctx context.Context, name string) (int64, error) {
+	var id int64
+	err := s.conn.QueryRowContext(ctx, `INSERT INTO teams (name) VALUES ($1) RETURNING id`, name).Scan(&id)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return 0, fmt.Errorf("%w: team %s already exists", constants.ErrAlreadyExists, name)
+		}
+		return 0, fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return id, nil
+}
+
+// DeleteTeam удаляет команду вместе с ее подключениями
+func (s *Storage) DeleteTeam(ctx context.Context, id int64) error {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: team %d", constants.ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *Storage) AddTeamMember(ctx context.Context, m shema.TeamMember) error {
+	_, err := s.conn.ExecContext(ctx, `
+		INSERT INTO team_members (team_id, login) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, m.TeamID, m.Login)
+	if err != nil {
+		if strings.Contains(err.Error(), "foreign key constraint") {
+			return fmt.Errorf("%w: no such team or user", constants.ErrNotFound)
+		}
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveTeamMember(ctx context.Context, m shema.TeamMember) error {
+	res, err := s.conn.ExecContext(ctx, `DELETE FROM team_members WHERE team_id = $1 AND login = $2`, m.TeamID, m.Login)
+	if err != nil {
+		return fmt.Errorf("unable to execute the query. %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s is not a member of team %d", constants.ErrNotFound, m.Login, m.TeamID)
+	}
+	return nil
+}
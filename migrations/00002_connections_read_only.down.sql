@@ -0,0 +1,2 @@
+ALTER TABLE connections
+    DROP COLUMN readOnly;
@@ -0,0 +1,2 @@
+ALTER TABLE connections
+    ADD COLUMN readOnly BOOLEAN NOT NULL DEFAULT FALSE;
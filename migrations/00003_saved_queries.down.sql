@@ -0,0 +1,2 @@
+DROP TABLE saved_query_shares;
+DROP TABLE saved_queries;
@@ -0,0 +1,23 @@
+CREATE TABLE saved_queries (
+                         id SERIAL PRIMARY KEY,
+                         login VARCHAR(255) NOT NULL,
+                         name VARCHAR(255) NOT NULL,
+                         description TEXT NOT NULL DEFAULT '',
+                         query TEXT NOT NULL,
+                         typeDB TEXT NOT NULL DEFAULT '',
+                         tags TEXT[] NOT NULL DEFAULT '{}',
+                         params JSONB NOT NULL DEFAULT '[]',
+                         created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                         updated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                         CONSTRAINT unique_saved_query_name UNIQUE (login, name)
+);
+
+CREATE INDEX saved_queries_tags ON saved_queries USING GIN (tags);
+
+CREATE TABLE saved_query_shares (
+                         query_id INT NOT NULL REFERENCES saved_queries (id) ON DELETE CASCADE,
+                         login VARCHAR(255) NOT NULL,
+                         PRIMARY KEY (query_id, login)
+);
+
+CREATE INDEX saved_query_shares_login ON saved_query_shares (login);
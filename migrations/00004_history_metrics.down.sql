@@ -0,0 +1,7 @@
+DROP INDEX history_login_time;
+
+ALTER TABLE history
+    DROP COLUMN status,
+    DROP COLUMN error,
+    DROP COLUMN durationMs,
+    DROP COLUMN rowCount;
@@ -0,0 +1,7 @@
+ALTER TABLE history
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'ok',
+    ADD COLUMN error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN durationMs BIGINT,
+    ADD COLUMN rowCount BIGINT;
+
+CREATE INDEX history_login_time ON history (login, time DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE history
+    DROP COLUMN clientIP,
+    DROP COLUMN source;
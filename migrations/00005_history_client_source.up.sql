@@ -0,0 +1,3 @@
+ALTER TABLE history
+    ADD COLUMN clientIP TEXT NOT NULL DEFAULT '',
+    ADD COLUMN source TEXT NOT NULL DEFAULT 'query';
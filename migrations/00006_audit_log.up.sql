@@ -0,0 +1,30 @@
+CREATE TABLE audit_log (
+        id BIGSERIAL PRIMARY KEY,
+        time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+        actor VARCHAR(255) NOT NULL,
+        action TEXT NOT NULL,
+        success BOOLEAN NOT NULL,
+        connectionId BIGINT,
+        typeDB TEXT NOT NULL DEFAULT '',
+        dbName VARCHAR(255) NOT NULL DEFAULT '',
+        queryHash TEXT NOT NULL DEFAULT '',
+        clientIP TEXT NOT NULL DEFAULT '',
+        detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_time ON audit_log (time DESC);
+CREATE INDEX audit_log_actor_time ON audit_log (actor, time DESC);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only, % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
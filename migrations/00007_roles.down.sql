@@ -0,0 +1,5 @@
+DROP TABLE connection_grants;
+
+ALTER TABLE users
+    DROP CONSTRAINT users_role,
+    DROP COLUMN role;
@@ -0,0 +1,15 @@
+-- у существующих пользователей остаются прежние права, новые регистрируются наблюдателями
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'editor',
+    ADD CONSTRAINT users_role CHECK (role IN ('admin', 'editor', 'viewer'));
+
+ALTER TABLE users
+    ALTER COLUMN role SET DEFAULT 'viewer';
+
+CREATE TABLE connection_grants (
+                         connection_id INT NOT NULL REFERENCES connections (id) ON DELETE CASCADE,
+                         login VARCHAR(255) NOT NULL REFERENCES users (login) ON DELETE CASCADE,
+                         PRIMARY KEY (connection_id, login)
+);
+
+CREATE INDEX connection_grants_login ON connection_grants (login);
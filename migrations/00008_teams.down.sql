@@ -0,0 +1,5 @@
+ALTER TABLE connections
+    DROP COLUMN team_id;
+
+DROP TABLE team_members;
+DROP TABLE teams;
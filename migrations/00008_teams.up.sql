@@ -0,0 +1,20 @@
+CREATE TABLE teams (
+                         id SERIAL PRIMARY KEY,
+                         name VARCHAR(255) NOT NULL,
+                         created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                         CONSTRAINT unique_team_name UNIQUE (name)
+);
+
+CREATE TABLE team_members (
+                         team_id INT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+                         login VARCHAR(255) NOT NULL REFERENCES users (login) ON DELETE CASCADE,
+                         PRIMARY KEY (team_id, login)
+);
+
+CREATE INDEX team_members_login ON team_members (login);
+
+-- подключение команды: login - кто его создал, пользоваться могут все участники
+ALTER TABLE connections
+    ADD COLUMN team_id INT REFERENCES teams (id) ON DELETE CASCADE;
+
+CREATE INDEX connections_team ON connections (team_id);
@@ -0,0 +1,14 @@
+-- серверные сессии: в cookie лежит подписанный токен, в таблице - его sha256 и данные сессии
+CREATE TABLE sessions (
+                         id CHAR(64) PRIMARY KEY,
+                         login VARCHAR(255) REFERENCES users (login) ON DELETE CASCADE,
+                         data BYTEA NOT NULL,
+                         clientIP VARCHAR(64) NOT NULL DEFAULT '',
+                         userAgent TEXT NOT NULL DEFAULT '',
+                         created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                         updated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                         expires TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX sessions_login ON sessions (login);
+CREATE INDEX sessions_expires ON sessions (expires);